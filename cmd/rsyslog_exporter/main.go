@@ -26,7 +26,10 @@ import (
 	"syscall"
 	"time"
 
-	exporter "github.com/prometheus-community/rsyslog_exporter/internal/exporter"
+	"github.com/prometheus-community/rsyslog_exporter/decoder"
+	exporter "github.com/prometheus-community/rsyslog_exporter/exporter"
+	"github.com/prometheus-community/rsyslog_exporter/internal/input"
+	"github.com/prometheus-community/rsyslog_exporter/internal/rsyslog"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -38,6 +41,28 @@ var (
 	certPath      = flag.String("tls.server-crt", "", "Path to PEM encoded file containing TLS server cert.")
 	keyPath       = flag.String("tls.server-key", "", "Path to PEM encoded file containing TLS server key (unencrypted).")
 	silent        = flag.Bool("silent", false, "Disable logging of errors in handling stats lines")
+	debug         = flag.Bool("debug", false, "Log the type of every stats line and the rule that classified it.")
+	udpAddress    = flag.String("input.udp-address", "", "Address to receive impstats on via syslog over UDP (e.g. omfwd). Disables reading from stdin.")
+	tcpAddress    = flag.String("input.tcp-address", "", "Address to receive impstats on via syslog over TCP (e.g. omfwd). Disables reading from stdin.")
+	unixSocket    = flag.String("input.unix-socket", "", "Path of a Unix socket to receive impstats on (e.g. omuxsock). Disables reading from stdin.")
+	unixType      = flag.String("input.unix-socket-type", input.UnixDatagram, "Type of the Unix socket given by input.unix-socket: datagram or stream.")
+	statsFile     = flag.String("input.file", "", "Path of an impstats log file (log.file=) to follow. Disables reading from stdin.")
+	positionFile  = flag.String("input.file-position", "", "Path of a file persisting the read offset of input.file across restarts.")
+	filePoll      = flag.Duration("input.file-poll-interval", input.DefaultPollInterval, "How often input.file is checked for new data and rotation.")
+	inputFormat   = flag.String("input.format", rsyslog.FormatAuto.String(), "impstats output format: auto, json, cee, json-elasticsearch or legacy.")
+	framing       = flag.String("input.framing", exporter.FramingColumns, "How stats lines are split into header and payload: columns, auto, raw or regex.")
+	framingRegex  = flag.String("input.framing-regex", "", "Prefix regular expression for input.framing=regex; named groups timestamp, hostname and tag are captured.")
+	hostLabel     = flag.Bool("input.host-label", false, "Label every metric with the hostname of the reporting rsyslog instance, to aggregate several hosts.")
+	genericStats  = flag.Bool("input.generic-decoder", false, "Export every numeric field of impstats objects of unknown type as rsyslog_stat_value instead of counting them as errors.")
+	expiryTTL     = flag.Duration("expiry.ttl", 0, "Drop series not updated for this long, e.g. after a config reload removed their object. 0 keeps them forever.")
+	expiryMissed  = flag.Int("expiry.missed-intervals", 0, "Drop series not updated for this many detected impstats intervals. 0 disables.")
+	expiryPerType = flag.String("expiry.per-type", "", "Per object type expiry overriding the defaults, e.g. \"dynstat=10m,action=3,queue=never\".")
+	monotonic     = flag.Bool("counters.monotonic", false, "Keep counters monotonic across rsyslog restarts by adding up their values.")
+	counterMode   = flag.String("counters.mode", exporter.CounterAbsolute.String(), "How impstats reports counters: absolute, or delta for resetCounters=\"on\".")
+	counterTypes  = flag.String("counters.mode-per-type", "", "Per object type counter modes overriding counters.mode, e.g. \"dynstat=delta\" for resettable dynstats.")
+	persistFile   = flag.String("persist.file", "", "Path of a file the metric store is saved to and restored from across exporter restarts.")
+	persistEvery  = flag.Duration("persist.interval", exporter.DefaultPersistInterval, "How often the metric store is saved to persist.file.")
+	persistMaxAge = flag.Duration("persist.max-age", 10*time.Minute, "Ignore a persist.file saved longer ago than this. 0 restores any age.")
 )
 
 // test hooks
@@ -64,18 +89,30 @@ func setupSyslog() io.Writer {
 func main() {
 	_ = setupSyslog()
 	flag.Parse()
-	re := exporter.New()
+	opts, err := exporterOptions()
+	if err != nil {
+		exitOnErr(err)
+		return
+	}
+	re := exporter.New(opts...)
 
 	// root context for the application; cancel on shutdown to allow
 	// future components to observe cancellation.
 	ctx, cancel := makeRootContext()
 	defer cancel()
 
-	// start exporter loop (reads stdin until EOF). Pass root context so
-	// it can be canceled on shutdown.
+	// start exporter loop (reads stdin until EOF, or the configured
+	// listeners and files). Pass root context so it can be canceled on shutdown.
+	runDone := make(chan struct{})
 	go func() {
+		defer close(runDone)
 		if err := re.Run(ctx, *silent); err != nil {
 			log.Printf("exporter run ended with error: %v", err)
+			// a daemon serving metrics without its configured input
+			// would hide the problem
+			if hasInputSources() && !errors.Is(err, context.Canceled) {
+				exitOnErr(err)
+			}
 		} else {
 			log.Print("exporter run ended normally")
 		}
@@ -110,8 +147,13 @@ func main() {
 		} else {
 			log.Print("server shutdown complete")
 		}
-		// cancel root context so other components can stop if wired up
+		// cancel root context so other components can stop if wired up,
+		// and let the exporter save its state
 		cancel()
+		select {
+		case <-runDone:
+		case <-shutdownCtx.Done():
+		}
 		// ensure the shutdown timeout context is cancelled before exiting
 		shutdownCancel()
 		osExit(0)
@@ -133,6 +175,85 @@ func main() {
 	}
 }
 
+// hasInputSources reports whether a listener, socket or file is configured
+// as input instead of stdin.
+func hasInputSources() bool {
+	return *udpAddress != "" || *tcpAddress != "" || *unixSocket != "" || *statsFile != ""
+}
+
+// exporterOptions configures the exporter from the command line flags.
+// Without any listener or file configured the exporter reads from stdin.
+func exporterOptions() ([]exporter.Option, error) {
+	format, err := rsyslog.ParseFormat(*inputFormat)
+	if err != nil {
+		return nil, err
+	}
+	framer, err := exporter.NewFramer(*framing, *framingRegex)
+	if err != nil {
+		return nil, err
+	}
+	perType, err := exporter.ParseExpiryOverrides(*expiryPerType, decoder.Default())
+	if err != nil {
+		return nil, err
+	}
+	expiry := exporter.Expiry{
+		Default: exporter.ExpiryPolicy{TTL: *expiryTTL, MissedIntervals: *expiryMissed},
+		PerType: perType,
+	}
+	modes, err := counterModes()
+	if err != nil {
+		return nil, err
+	}
+	opts := []exporter.Option{
+		exporter.WithFormat(format),
+		exporter.WithFraming(framer),
+		exporter.WithHostLabel(*hostLabel),
+		exporter.WithGenericDecoder(*genericStats),
+		exporter.WithDebug(*debug),
+		exporter.WithExpiry(expiry),
+		exporter.WithMonotonicCounters(*monotonic),
+		exporter.WithCounterModes(modes),
+	}
+
+	var sources []input.Source
+	if *udpAddress != "" {
+		sources = append(sources, input.NewUDPSource(*udpAddress))
+	}
+	if *tcpAddress != "" {
+		sources = append(sources, input.NewTCPSource(*tcpAddress))
+	}
+	if *unixSocket != "" {
+		src, err := input.NewUnixSource(*unixSocket, *unixType)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, src)
+	}
+	if *statsFile != "" {
+		sources = append(sources, input.NewFileSource(*statsFile, *positionFile, *filePoll))
+	}
+	if len(sources) > 0 {
+		opts = append(opts, exporter.WithSource(input.Merge(sources...)))
+	}
+	if *persistFile != "" {
+		opts = append(opts, exporter.WithPersistence(*persistFile, *persistEvery, *persistMaxAge))
+	}
+	return opts, nil
+}
+
+// counterModes returns the counter modes configured by the flags.
+func counterModes() (exporter.CounterModes, error) {
+	mode, err := exporter.ParseCounterMode(*counterMode)
+	if err != nil {
+		return exporter.CounterModes{}, err
+	}
+	perType, err := exporter.ParseCounterModeOverrides(*counterTypes, decoder.Default())
+	if err != nil {
+		return exporter.CounterModes{}, err
+	}
+	return exporter.CounterModes{Default: mode, PerType: perType}, nil
+}
+
 // registerHandlers wires endpoints onto mux using provided registry.
 func registerHandlers(mux *http.ServeMux, metricPath string, re *exporter.Exporter, reg *prometheus.Registry) {
 	// safe register: ignore AlreadyRegistered
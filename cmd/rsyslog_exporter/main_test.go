@@ -30,7 +30,7 @@ import (
 	"testing"
 	"time"
 
-	exporter "github.com/prometheus-community/rsyslog_exporter/internal/exporter"
+	exporter "github.com/prometheus-community/rsyslog_exporter/exporter"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -111,6 +111,68 @@ func TestRegisterHandlersWithCustomMetricPath(t *testing.T) {
 	}
 }
 
+func TestExporterOptions(t *testing.T) {
+	origUDP, origTCP, origUnix, origType, origFile := *udpAddress, *tcpAddress, *unixSocket, *unixType, *statsFile
+	origFormat, origFraming, origRegex, origPerType := *inputFormat, *framing, *framingRegex, *expiryPerType
+	origMode, origModeTypes := *counterMode, *counterTypes
+	defer func() {
+		*udpAddress, *tcpAddress, *unixSocket, *unixType, *statsFile = origUDP, origTCP, origUnix, origType, origFile
+		*inputFormat, *framing, *framingRegex, *expiryPerType = origFormat, origFraming, origRegex, origPerType
+		*counterMode, *counterTypes = origMode, origModeTypes
+	}()
+
+	*udpAddress, *tcpAddress, *unixSocket, *statsFile = "", "", "", ""
+	if opts, err := exporterOptions(); err != nil || len(opts) != 8 {
+		t.Fatalf("expected only the flag options without listeners, got %d (err %v)", len(opts), err)
+	}
+
+	*udpAddress, *tcpAddress, *unixSocket = ":5140", ":5140", "/run/rsyslog_exporter.sock"
+	*statsFile = "/var/log/impstats.log"
+	if opts, err := exporterOptions(); err != nil || len(opts) != 9 {
+		t.Fatalf("expected the flag options and a single source option, got %d (err %v)", len(opts), err)
+	}
+
+	origPersist := *persistFile
+	*persistFile = "/var/lib/rsyslog_exporter/store.json"
+	opts, err := exporterOptions()
+	*persistFile = origPersist
+	if err != nil || len(opts) != 10 {
+		t.Fatalf("expected an additional persistence option, got %d (err %v)", len(opts), err)
+	}
+
+	*unixType = "bogus"
+	if _, err := exporterOptions(); err == nil {
+		t.Fatalf("expected error for invalid unix socket type")
+	}
+
+	*inputFormat = "xml"
+	if _, err := exporterOptions(); err == nil {
+		t.Fatalf("expected error for invalid input format")
+	}
+	*inputFormat, *unixType = origFormat, origType
+
+	*framing, *framingRegex = exporter.FramingRegex, ""
+	if _, err := exporterOptions(); err == nil {
+		t.Fatalf("expected error for regex framing without a pattern")
+	}
+	*framing = origFraming
+
+	*expiryPerType = "bogus=10m"
+	if _, err := exporterOptions(); err == nil {
+		t.Fatalf("expected error for expiry of an unknown object type")
+	}
+	*expiryPerType = origPerType
+
+	*counterMode = "cumulative"
+	if _, err := exporterOptions(); err == nil {
+		t.Fatalf("expected error for an unknown counter mode")
+	}
+	*counterMode, *counterTypes = origMode, "queue=sometimes"
+	if _, err := exporterOptions(); err == nil {
+		t.Fatalf("expected error for an unknown per type counter mode")
+	}
+}
+
 func TestBuildServerConfig(t *testing.T) {
 	mux := http.NewServeMux()
 	srv := buildServer(":0", mux)
@@ -207,6 +269,51 @@ func TestMainFunctionCoversExitPath(t *testing.T) {
 	}
 }
 
+func TestMainExitsOnInputError(t *testing.T) {
+	*listenAddress = "127.0.0.1:0"
+	*metricPath = defaultMetricPath
+	*certPath = ""
+	*keyPath = ""
+	*silent = true
+	origUDP := *udpAddress
+	defer func() { *udpAddress = origUDP }()
+	*udpAddress = "127.0.0.1:-1"
+
+	origExit := osExit
+	defer func() { osExit = origExit }()
+	gotExit := make(chan int, 1)
+	osExit = func(code int) { gotExit <- code }
+
+	origFatal := exitOnErr
+	defer func() { exitOnErr = origFatal }()
+	gotErr := make(chan error, 1)
+	exitOnErr = func(err error) { gotErr <- err }
+
+	// the exit hook returns, so stop main through its root context before
+	// the hooks are restored
+	origMk := makeRootContext
+	defer func() { makeRootContext = origMk }()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	makeRootContext = func() (context.Context, context.CancelFunc) { return ctx, cancel }
+
+	go main()
+	select {
+	case e := <-gotErr:
+		if e == nil || !strings.Contains(e.Error(), "udp") {
+			t.Fatalf("expected the udp listener error, got %v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("exit hook was not invoked for a failing input")
+	}
+	cancel()
+	select {
+	case <-gotExit:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("main did not return after cancellation")
+	}
+}
+
 func TestMainFunctionCoversTLSBranch(t *testing.T) {
 	*listenAddress = invalidListenAddr
 	*metricPath = defaultMetricPath
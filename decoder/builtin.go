@@ -0,0 +1,66 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decoder
+
+import (
+	"fmt"
+
+	"github.com/prometheus-community/rsyslog_exporter/internal/rsyslog"
+)
+
+// builtin decodes an object type rsyslog itself emits. Builtins are
+// selected together by rsyslog.Classify rather than one by one.
+type builtin struct {
+	typ    rsyslog.Type
+	decode func(f *rsyslog.Fields) []*Point
+}
+
+func (b *builtin) Name() string          { return b.typ.String() }
+func (b *builtin) Match(obj Object) bool { return obj.classify().Type == b.typ }
+
+func (b *builtin) Decode(obj Object) ([]*Point, error) {
+	if err := obj.parsed(); err != nil {
+		return nil, fmt.Errorf("failed to decode %s stat `%v`: %w", b.Name(), string(obj.Raw), err)
+	}
+	return b.decode(obj.fields), nil
+}
+
+// builtins are the decoders of the objects rsyslog emits.
+var builtins = []*builtin{
+	{rsyslog.TypeAction, func(f *rsyslog.Fields) []*Point { return rsyslog.NewActionFromFields(f).ToPoints() }},
+	{rsyslog.TypeInput, func(f *rsyslog.Fields) []*Point { return rsyslog.NewInputFromFields(f).ToPoints() }},
+	{rsyslog.TypeInputIMDUP, func(f *rsyslog.Fields) []*Point { return rsyslog.NewInputIMUDPFromFields(f).ToPoints() }},
+	{rsyslog.TypeQueue, func(f *rsyslog.Fields) []*Point { return rsyslog.NewQueueFromFields(f).ToPoints() }},
+	{rsyslog.TypeResource, func(f *rsyslog.Fields) []*Point { return rsyslog.NewResourceFromFields(f).ToPoints() }},
+	{rsyslog.TypeDynStat, func(f *rsyslog.Fields) []*Point { return rsyslog.NewDynStatFromFields(f).ToPoints() }},
+	{rsyslog.TypeDynafileCache, func(f *rsyslog.Fields) []*Point { return rsyslog.NewDynafileCacheFromFields(f).ToPoints() }},
+	{rsyslog.TypeForward, func(f *rsyslog.Fields) []*Point { return rsyslog.NewForwardFromFields(f).ToPoints() }},
+	{rsyslog.TypeKubernetes, func(f *rsyslog.Fields) []*Point { return rsyslog.NewKubernetesFromFields(f).ToPoints() }},
+	{rsyslog.TypeOmkafka, func(f *rsyslog.Fields) []*Point { return rsyslog.NewOmkafkaFromFields(f).ToPoints() }},
+}
+
+// generic decodes objects no other decoder matches, see Generic.
+var generic = New(rsyslog.TypeUnknown.String(), func(Object) bool { return true }, func(obj Object) ([]*Point, error) {
+	if err := obj.parsed(); err != nil {
+		return nil, fmt.Errorf("failed to decode generic stat `%v`: %w", string(obj.Raw), err)
+	}
+	return rsyslog.NewGenericFromFields(obj.fields).ToPoints(), nil
+})
+
+// Generic returns the decoder exporting every numeric field of an object as
+// rsyslog_stat_value{name, origin, field}. It matches any object and is
+// meant as the last resort for objects of unknown type.
+func Generic() Decoder {
+	return generic
+}
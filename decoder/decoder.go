@@ -0,0 +1,165 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package decoder turns rsyslog impstats objects into metric points. It
+// holds the decoders of the objects rsyslog itself emits and lets programs
+// embedding the exporter register decoders for further plugins.
+package decoder
+
+import (
+	"fmt"
+
+	"github.com/prometheus-community/rsyslog_exporter/internal/model"
+	"github.com/prometheus-community/rsyslog_exporter/internal/rsyslog"
+)
+
+// Point is a single metric sample decoded from an impstats object. Its
+// Name is exported with the "rsyslog_" prefix.
+type Point = model.Point
+
+// Label is a name/value pair of a Point.
+type Label = model.Label
+
+// PointType tells whether a Point is a counter, a gauge or an info metric.
+type PointType = model.PointType
+
+const (
+	Counter = model.Counter
+	Gauge   = model.Gauge
+	Info    = model.Info
+)
+
+// Object is an impstats object as seen by Decoder.Match and Decoder.Decode.
+// Its fields are split once, when the object is parsed, and shared by all
+// decoders asked about it.
+type Object struct {
+	// Name and Origin are the "name" and "origin" fields, empty if absent.
+	Name   string
+	Origin string
+	// Raw is the whole object.
+	Raw []byte
+
+	fields *rsyslog.Fields
+	// err is why Raw could not be parsed, if it could not.
+	err error
+}
+
+// NewObject parses the JSON impstats object buf.
+func NewObject(buf []byte) (Object, error) {
+	return new(Parser).Parse(buf)
+}
+
+// Has reports whether the object has the top-level field key.
+func (o Object) Has(key string) bool {
+	return o.fields != nil && o.fields.Has(key)
+}
+
+// Value returns the raw JSON of the top-level field key, or nil if there is
+// none.
+func (o Object) Value(key string) []byte {
+	if o.fields == nil {
+		return nil
+	}
+	return o.fields.Value(key)
+}
+
+// String returns the string field key, or "" if it is missing or not a
+// string.
+func (o Object) String(key string) string {
+	if o.fields == nil {
+		return ""
+	}
+	return o.fields.String(key)
+}
+
+// Number returns the numeric field key like the builtin decoders read
+// theirs: 0 if it is missing, NaN if it is not a number. Numbers encoded as
+// strings are accepted.
+func (o Object) Number(key string) float64 {
+	if o.fields == nil {
+		return 0
+	}
+	return float64(o.fields.Number(key))
+}
+
+// parsed returns why the object could not be parsed, or nil if it was.
+func (o Object) parsed() error {
+	if o.err == nil && o.fields == nil {
+		return rsyslog.ErrNotObject
+	}
+	return o.err
+}
+
+// classify returns the builtin type of the object.
+func (o Object) classify() rsyslog.Classification {
+	if o.parsed() != nil {
+		return rsyslog.ClassifyLine(o.Raw)
+	}
+	return rsyslog.ClassifyFields(o.fields)
+}
+
+// Parser parses impstats objects, reusing its memory from one object to the
+// next. An Object returned by Parse is valid until the next call of Parse.
+// A Parser must not be used concurrently.
+type Parser struct {
+	fields rsyslog.Fields
+}
+
+// Parse parses the JSON impstats object buf. If buf is not a JSON object,
+// the returned Object only carries Raw; builtin decoders still classify it
+// by its content, and fail to decode it.
+func (p *Parser) Parse(buf []byte) (Object, error) {
+	obj := Object{Raw: buf, fields: &p.fields}
+	if err := p.fields.Parse(buf); err != nil {
+		obj.err = err
+		return obj, fmt.Errorf("failed to decode stat `%v`: %w", string(buf), err)
+	}
+	obj.Name = p.fields.String("name")
+	obj.Origin = p.fields.String("origin")
+	return obj, nil
+}
+
+// Decoder decodes one type of impstats object.
+type Decoder interface {
+	// Name is the object type, e.g. "queue". It selects per type settings
+	// such as expiry and counter modes.
+	Name() string
+	// Match reports whether the decoder handles obj.
+	Match(obj Object) bool
+	// Decode returns the points of obj.
+	Decode(obj Object) ([]*Point, error)
+}
+
+// funcDecoder is a Decoder made of functions.
+type funcDecoder struct {
+	name   string
+	match  func(Object) bool
+	decode func(Object) ([]*Point, error)
+}
+
+// New returns a Decoder of the object type name from a match and a decode
+// function.
+func New(name string, match func(Object) bool, decode func(Object) ([]*Point, error)) Decoder {
+	return &funcDecoder{name: name, match: match, decode: decode}
+}
+
+func (d *funcDecoder) Name() string                        { return d.name }
+func (d *funcDecoder) Match(obj Object) bool               { return d.match(obj) }
+func (d *funcDecoder) Decode(obj Object) ([]*Point, error) { return d.decode(obj) }
+
+// MatchOrigin returns a match function accepting objects of origin.
+func MatchOrigin(origin string) func(Object) bool {
+	return func(obj Object) bool {
+		return obj.Origin == origin
+	}
+}
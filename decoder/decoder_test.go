@@ -0,0 +1,107 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decoder
+
+import (
+	"testing"
+
+	th "github.com/prometheus-community/rsyslog_exporter/internal/testhelpers"
+)
+
+func TestNewObject(t *testing.T) {
+	obj, err := NewObject([]byte(`{"name":"foo0","origin":"imfoo","opened":1}`))
+	if err != nil {
+		t.Fatalf("NewObject failed: %v", err)
+	}
+	th.AssertEqString(t, "name", "foo0", obj.Name)
+	th.AssertEqString(t, "origin", "imfoo", obj.Origin)
+	if !obj.Has("opened") || obj.Has("closed") {
+		t.Errorf("unexpected fields in %s", obj.Raw)
+	}
+	th.AssertEqFloat(t, "opened", 1, obj.Number("opened"))
+	th.AssertEqString(t, "raw value", "1", string(obj.Value("opened")))
+
+	// a non-string name is ignored
+	if obj, err := NewObject([]byte(`{"name":1}`)); err != nil || obj.Name != "" {
+		t.Errorf("want empty name, got %q (err %v)", obj.Name, err)
+	}
+	if _, err := NewObject([]byte("not json")); err == nil {
+		t.Errorf("expected error for invalid JSON")
+	}
+}
+
+func TestBuiltinMatch(t *testing.T) {
+	obj, err := NewObject([]byte(`{"name":"main Q","origin":"core.queue","enqueued":1}`))
+	if err != nil {
+		t.Fatalf("NewObject failed: %v", err)
+	}
+	matched := 0
+	for _, b := range builtins {
+		if b.Match(obj) {
+			matched++
+			th.AssertEqString(t, "matched", "queue", b.Name())
+		}
+	}
+	if matched != 1 {
+		t.Errorf("want exactly one builtin to match, got %d", matched)
+	}
+}
+
+func TestGeneric(t *testing.T) {
+	d := Generic()
+	th.AssertEqString(t, "name", "unknown", d.Name())
+	if !d.Match(Object{}) {
+		t.Errorf("expected generic decoder to match anything")
+	}
+	obj, _ := NewObject([]byte(`{"name":"foo0","origin":"imfoo","opened":1}`))
+	points, err := d.Decode(obj)
+	if err != nil || len(points) != 1 {
+		t.Fatalf("expected one point, got %d (err %v)", len(points), err)
+	}
+	th.AssertEqString(t, "point", "stat_value", points[0].Name)
+	obj, _ = NewObject([]byte("not json"))
+	if _, err := d.Decode(obj); err == nil {
+		t.Errorf("expected error for invalid JSON")
+	}
+}
+
+func TestParserReuse(t *testing.T) {
+	var p Parser
+	first, err := p.Parse([]byte(`{"name":"main Q","origin":"core.queue","enqueued":1}`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	th.AssertEqString(t, "name", "main Q", first.Name)
+	second, err := p.Parse([]byte(`{"name":"foo0","origin":"imfoo","opened":1}`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if !second.Has("opened") || second.Has("enqueued") {
+		t.Errorf("expected only the fields of the last object in %s", second.Raw)
+	}
+
+	// lines that are not JSON are still classified, but fail to decode
+	obj, err := p.Parse([]byte("enqueued=1"))
+	if err == nil {
+		t.Fatalf("expected error for invalid JSON")
+	}
+	d, rule := NewRegistry().Lookup(obj)
+	if d == nil {
+		t.Fatalf("expected the queue decoder by %s", rule)
+	}
+	th.AssertEqString(t, "rule", `substring "enqueued"`, rule)
+	if _, err := d.Decode(obj); err == nil {
+		t.Errorf("expected decode error for invalid JSON")
+	}
+}
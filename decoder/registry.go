@@ -0,0 +1,134 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decoder
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/prometheus-community/rsyslog_exporter/internal/rsyslog"
+)
+
+// ErrDuplicate is returned when registering a decoder under a name that is
+// already taken.
+var ErrDuplicate = errors.New("duplicate decoder name")
+
+// Registry selects the decoder of impstats objects. Registered decoders are
+// asked in registration order before the builtin ones, so they may also
+// take over objects rsyslog itself emits.
+type Registry struct {
+	lock     sync.RWMutex
+	custom   []Decoder
+	builtins map[rsyslog.Type]Decoder
+}
+
+// NewRegistry returns a registry holding the builtin decoders.
+func NewRegistry() *Registry {
+	r := &Registry{builtins: make(map[rsyslog.Type]Decoder, len(builtins))}
+	for _, b := range builtins {
+		r.builtins[b.typ] = b
+	}
+	return r
+}
+
+// Register adds d to the registry. Its name must not be taken by another
+// decoder, builtin ones included.
+func (r *Registry) Register(d Decoder) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.has(d.Name()) {
+		return fmt.Errorf("%w %q", ErrDuplicate, d.Name())
+	}
+	r.custom = append(r.custom, d)
+	return nil
+}
+
+// MustRegister is like Register but panics on error.
+func (r *Registry) MustRegister(d Decoder) {
+	if err := r.Register(d); err != nil {
+		panic(err)
+	}
+}
+
+func (r *Registry) has(name string) bool {
+	if name == generic.Name() {
+		return true
+	}
+	for _, d := range r.custom {
+		if d.Name() == name {
+			return true
+		}
+	}
+	for _, d := range r.builtins {
+		if d.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Has reports whether a decoder of the object type name is registered.
+func (r *Registry) Has(name string) bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.has(name)
+}
+
+// Names returns the sorted names of all decoders.
+func (r *Registry) Names() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	names := make([]string, 0, len(r.custom)+len(r.builtins))
+	for _, d := range r.custom {
+		names = append(names, d.Name())
+	}
+	for _, d := range r.builtins {
+		names = append(names, d.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the decoder of the impstats object obj, or nil if there is
+// none, together with a description of the rule that selected it.
+func (r *Registry) Lookup(obj Object) (Decoder, string) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	if obj.parsed() == nil {
+		for _, d := range r.custom {
+			if d.Match(obj) {
+				return d, fmt.Sprintf("decoder %q", d.Name())
+			}
+		}
+	}
+	class := obj.classify()
+	if d, ok := r.builtins[class.Type]; ok {
+		return d, class.Rule
+	}
+	return nil, class.Rule
+}
+
+var defaultRegistry = NewRegistry()
+
+// Default returns the registry the exporter uses unless given another one.
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// Register adds d to the default registry.
+func Register(d Decoder) error {
+	return defaultRegistry.Register(d)
+}
@@ -0,0 +1,136 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decoder
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	th "github.com/prometheus-community/rsyslog_exporter/internal/testhelpers"
+)
+
+// lookup parses buf and looks up its decoder in r.
+func lookup(t *testing.T, r *Registry, buf string) (Decoder, string, Object) {
+	t.Helper()
+	obj, err := NewObject([]byte(buf))
+	if err != nil {
+		t.Fatalf("NewObject failed: %v", err)
+	}
+	d, rule := r.Lookup(obj)
+	return d, rule, obj
+}
+
+// fooDecoder decodes the objects of a made up imfoo input module.
+var fooDecoder = New("imfoo", MatchOrigin("imfoo"), func(obj Object) ([]*Point, error) {
+	return []*Point{{
+		Name:   "imfoo_opened",
+		Type:   Counter,
+		Value:  obj.Number("opened"),
+		Labels: []Label{{Name: "input", Value: obj.Name}},
+	}}, nil
+})
+
+func TestRegistryBuiltins(t *testing.T) {
+	r := NewRegistry()
+	d, rule, obj := lookup(t, r, `{"name":"main Q","origin":"core.queue","enqueued":1}`)
+	if d == nil {
+		t.Fatalf("expected builtin queue decoder")
+	}
+	th.AssertEqString(t, "name", "queue", d.Name())
+	th.AssertEqString(t, "rule", `origin "core.queue"`, rule)
+	points, err := d.Decode(obj)
+	if err != nil || len(points) == 0 {
+		t.Fatalf("expected queue points, got %d (err %v)", len(points), err)
+	}
+
+	if d, rule, _ := lookup(t, r, `{"name":"x","origin":"imfoo","opened":1}`); d != nil {
+		t.Fatalf("expected no decoder, got %s by %s", d.Name(), rule)
+	}
+	for _, name := range []string{"queue", "action", "omkafka", "unknown"} {
+		if !r.Has(name) {
+			t.Errorf("expected decoder %q", name)
+		}
+	}
+}
+
+func TestRegistryCustom(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(fooDecoder)
+	d, rule, obj := lookup(t, r, `{"name":"foo0","origin":"imfoo","opened":1}`)
+	if d != fooDecoder {
+		t.Fatalf("expected custom decoder, got %v", d)
+	}
+	th.AssertEqString(t, "rule", `decoder "imfoo"`, rule)
+	points, err := d.Decode(obj)
+	if err != nil || len(points) != 1 {
+		t.Fatalf("expected one point, got %d (err %v)", len(points), err)
+	}
+	th.AssertEqString(t, "label", "foo0", points[0].Label("input"))
+
+	if !slices.Contains(r.Names(), "imfoo") {
+		t.Errorf("expected imfoo among %v", r.Names())
+	}
+	// other registries are unaffected
+	if NewRegistry().Has("imfoo") {
+		t.Errorf("expected imfoo only in the registry it was added to")
+	}
+}
+
+func TestRegistryCustomTakesPrecedence(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(New("inhouse_queue", func(o Object) bool { return o.Origin == "core.queue" && o.Name == "inhouse" }, nil))
+	if d, _, _ := lookup(t, r, `{"name":"inhouse","origin":"core.queue","enqueued":1}`); d == nil || d.Name() != "inhouse_queue" {
+		t.Fatalf("expected custom decoder to take over, got %v", d)
+	}
+	if d, _, _ := lookup(t, r, `{"name":"main Q","origin":"core.queue","enqueued":1}`); d == nil || d.Name() != "queue" {
+		t.Fatalf("expected builtin decoder for other queues, got %v", d)
+	}
+}
+
+func TestRegistryDuplicate(t *testing.T) {
+	r := NewRegistry()
+	for _, name := range []string{"queue", "unknown"} {
+		if err := r.Register(New(name, MatchOrigin("x"), nil)); !errors.Is(err, ErrDuplicate) {
+			t.Errorf("Register(%q): want ErrDuplicate, got %v", name, err)
+		}
+	}
+	r.MustRegister(fooDecoder)
+	if err := r.Register(fooDecoder); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("want ErrDuplicate registering twice, got %v", err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected MustRegister to panic")
+		}
+	}()
+	r.MustRegister(fooDecoder)
+}
+
+func TestDefaultRegistry(t *testing.T) {
+	if Default() != Default() {
+		t.Fatalf("expected a single default registry")
+	}
+	// register into a fresh default registry, so that repeated runs do not
+	// see the decoder of the previous one
+	orig := defaultRegistry
+	defer func() { defaultRegistry = orig }()
+	defaultRegistry = NewRegistry()
+	if err := Register(New("default_test", MatchOrigin("default_test"), nil)); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if !Default().Has("default_test") {
+		t.Errorf("expected decoder registered with Register in the default registry")
+	}
+}
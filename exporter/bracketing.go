@@ -0,0 +1,65 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/prometheus-community/rsyslog_exporter/internal/model"
+)
+
+// Markers impstats emits around every emission with bracketing="on".
+var (
+	bracketBegin = []byte("BEGIN")
+	bracketEnd   = []byte("END")
+)
+
+// isBracketMarker reports whether payload is a BEGIN or END marker.
+func isBracketMarker(payload []byte) bool {
+	payload = bytes.TrimSpace(payload)
+	return bytes.Equal(payload, bracketBegin) || bytes.Equal(payload, bracketEnd)
+}
+
+// handleBracketMarker opens or commits the batch of host. Points of an open
+// batch are staged by stage and only published to the store, all at once,
+// by the END marker, so that scrapes never mix two emissions.
+func (re *Exporter) handleBracketMarker(host string, payload []byte, at time.Time) {
+	staged, open := re.staged[host]
+	if bytes.Equal(bytes.TrimSpace(payload), bracketBegin) {
+		if open {
+			re.logger.Printf("impstats BEGIN without END from host %q, committing the open batch", host)
+			re.store.SetBatch(staged, at)
+		}
+		re.staged[host] = []*model.Point{}
+		return
+	}
+	// an END without BEGIN, e.g. when started during an emission, ends
+	// a batch whose points were already stored one by one.
+	if open {
+		delete(re.staged, host)
+		re.store.SetBatch(staged, at)
+	}
+}
+
+// stage adds points to the open batch of host. It reports false if no batch
+// is open, i.e. the points are to be stored right away.
+func (re *Exporter) stage(host string, points []*model.Point) bool {
+	staged, open := re.staged[host]
+	if !open {
+		return false
+	}
+	re.staged[host] = append(staged, points...)
+	return true
+}
@@ -0,0 +1,97 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"testing"
+
+	"github.com/prometheus-community/rsyslog_exporter/internal/model"
+)
+
+const bracketPrefix = "2025-03-04T12:00:00Z host rsyslogd-pstats: "
+
+func handleLines(t *testing.T, re *Exporter, payloads ...string) {
+	t.Helper()
+	for _, p := range payloads {
+		if err := re.handleStatLine([]byte(bracketPrefix + p)); err != nil {
+			t.Fatalf(handleStatLineFailMsg, err)
+		}
+	}
+}
+
+func enqueued(t *testing.T, re *Exporter, queue string) int64 {
+	t.Helper()
+	p, err := findPoint(re, "queue_enqueued", "queue", queue)
+	if err != nil {
+		return -1
+	}
+	return int64(p.Value)
+}
+
+func TestBracketingCommitsOnEnd(t *testing.T) {
+	re := New()
+	handleLines(t, re, "BEGIN", `{"name":"main Q","enqueued":1}`, `{"name":"action Q","enqueued":2}`)
+	if got := enqueued(t, re, "main Q"); got != -1 {
+		t.Fatalf("expected staged point not to be visible before END, got %d", got)
+	}
+
+	handleLines(t, re, "END")
+	if enqueued(t, re, "main Q") != 1 || enqueued(t, re, "action Q") != 2 {
+		t.Fatalf("expected the batch to be committed on END")
+	}
+
+	// the next emission replaces the values only once complete
+	handleLines(t, re, "BEGIN", `{"name":"main Q","enqueued":10}`)
+	if got := enqueued(t, re, "main Q"); got != 1 {
+		t.Fatalf("expected previous value during the emission, got %d", got)
+	}
+	handleLines(t, re, `{"name":"action Q","enqueued":20}`, "END")
+	if enqueued(t, re, "main Q") != 10 || enqueued(t, re, "action Q") != 20 {
+		t.Fatalf("expected the second batch to be committed on END")
+	}
+}
+
+func TestBracketingMissingMarkers(t *testing.T) {
+	re := New()
+	// END without BEGIN and unbracketed lines are stored right away
+	handleLines(t, re, `{"name":"main Q","enqueued":1}`, "END")
+	if got := enqueued(t, re, "main Q"); got != 1 {
+		t.Fatalf("expected unbracketed point to be stored, got %d", got)
+	}
+
+	// a lost END is made up for by the next BEGIN
+	handleLines(t, re, "BEGIN", `{"name":"main Q","enqueued":2}`, "BEGIN")
+	if got := enqueued(t, re, "main Q"); got != 2 {
+		t.Fatalf("expected open batch to be committed by BEGIN, got %d", got)
+	}
+}
+
+func TestBracketingPerHost(t *testing.T) {
+	re := New(WithHostLabel(true))
+	for _, l := range []string{
+		"ts relay-03 rsyslogd-pstats: BEGIN",
+		`ts relay-04 rsyslogd-pstats: {"name":"main Q","enqueued":4}`,
+		`ts relay-03 rsyslogd-pstats: {"name":"main Q","enqueued":3}`,
+	} {
+		if err := re.handleStatLine([]byte(l)); err != nil {
+			t.Fatalf(handleStatLineFailMsg, err)
+		}
+	}
+	if _, err := findPoint(re, "queue_enqueued", "queue", "main Q", model.HostLabelName, "relay-04"); err != nil {
+		t.Fatalf("expected point of an unbracketed host to be stored: %v", err)
+	}
+	if _, err := findPoint(re, "queue_enqueued", "queue", "main Q", model.HostLabelName, "relay-03"); err != model.ErrPointNotFound {
+		t.Fatalf("expected point of relay-03 to be staged, got %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/prometheus-community/rsyslog_exporter/decoder"
+)
+
+// CounterMode tells how rsyslog reports counter values.
+type CounterMode int
+
+const (
+	// CounterAbsolute values are running totals, the impstats default.
+	CounterAbsolute CounterMode = iota
+	// CounterDelta values count since the previous emission, as with
+	// impstats resetCounters="on" or dynstats resettable="on". They are
+	// added up into running totals.
+	CounterDelta
+)
+
+var counterModeNames = map[CounterMode]string{
+	CounterAbsolute: "absolute",
+	CounterDelta:    "delta",
+}
+
+func (m CounterMode) String() string {
+	if name, ok := counterModeNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("CounterMode(%d)", int(m))
+}
+
+// ParseCounterMode returns the CounterMode named s.
+func ParseCounterMode(s string) (CounterMode, error) {
+	for m, name := range counterModeNames {
+		if name == s {
+			return m, nil
+		}
+	}
+	return CounterAbsolute, fmt.Errorf("unknown counter mode %q: want absolute or delta", s)
+}
+
+// CounterModes holds the counter mode of all object types and per type,
+// keyed by type name such as "dynstat".
+type CounterModes struct {
+	Default CounterMode
+	PerType map[string]CounterMode
+}
+
+func (c CounterModes) mode(objectType string) CounterMode {
+	if m, ok := c.PerType[objectType]; ok {
+		return m
+	}
+	return c.Default
+}
+
+// ParseCounterModeOverrides parses per object type counter modes given as
+// a comma separated list of type=mode pairs, e.g. "dynstat=delta". Types
+// must name a decoder of r, the registry the exporter decodes with.
+func ParseCounterModeOverrides(s string, r *decoder.Registry) (map[string]CounterMode, error) {
+	overrides := map[string]CounterMode{}
+	if s == "" {
+		return overrides, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		typ, name, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid counter mode override %q: want type=mode", pair)
+		}
+		if !r.Has(typ) {
+			return nil, fmt.Errorf("invalid counter mode override %q: unknown object type %q", pair, typ)
+		}
+		m, err := ParseCounterMode(name)
+		if err != nil {
+			return nil, err
+		}
+		overrides[typ] = m
+	}
+	return overrides, nil
+}
+
+// WithCounterModes sets how rsyslog reports counter values. By default all
+// counters are taken as running totals.
+func WithCounterModes(modes CounterModes) Option {
+	return func(e *Exporter) {
+		e.restarts.modes = modes
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/prometheus-community/rsyslog_exporter/decoder"
+	th "github.com/prometheus-community/rsyslog_exporter/internal/testhelpers"
+)
+
+func TestParseCounterMode(t *testing.T) {
+	for m, name := range counterModeNames {
+		got, err := ParseCounterMode(name)
+		if err != nil || got != m {
+			t.Errorf("ParseCounterMode(%q): want %v, got %v (err %v)", name, m, got, err)
+		}
+		th.AssertEqString(t, "String", name, m.String())
+	}
+	if _, err := ParseCounterMode("cumulative"); err == nil {
+		t.Errorf("expected error for unknown counter mode")
+	}
+	th.AssertEqString(t, "unknown String", "CounterMode(7)", CounterMode(7).String())
+}
+
+func TestParseCounterModeOverrides(t *testing.T) {
+	got, err := ParseCounterModeOverrides("dynstat=delta, resource=absolute", decoder.Default())
+	if err != nil {
+		t.Fatalf("ParseCounterModeOverrides failed: %v", err)
+	}
+	if got["dynstat"] != CounterDelta || got["resource"] != CounterAbsolute {
+		t.Errorf("unexpected overrides %v", got)
+	}
+	if got, err := ParseCounterModeOverrides("", decoder.Default()); err != nil || len(got) != 0 {
+		t.Errorf("expected no overrides for empty input, got %v (err %v)", got, err)
+	}
+	for _, in := range []string{"dynstat", "bogus=delta", "queue=sometimes"} {
+		if _, err := ParseCounterModeOverrides(in, decoder.Default()); err == nil {
+			t.Errorf("ParseCounterModeOverrides(%q): expected error", in)
+		}
+	}
+
+	reg := decoder.NewRegistry()
+	reg.MustRegister(decoder.New("imfoo", decoder.MatchOrigin("imfoo"), nil))
+	if _, err := ParseCounterModeOverrides("imfoo=delta", reg); err != nil {
+		t.Errorf("expected type of a custom registry to be accepted: %v", err)
+	}
+}
+
+func TestDeltaCounters(t *testing.T) {
+	re := New(WithCounterModes(CounterModes{
+		Default: CounterDelta,
+		PerType: map[string]CounterMode{"resource": CounterAbsolute},
+	}))
+	// with resetCounters="on" queue counters are deltas, resource usage
+	// still running totals
+	emission(t, re, 0, 100, 5000)
+	emission(t, re, 10*time.Second, 20, 6000)
+	emission(t, re, 20*time.Second, 5, 7000)
+	if got := enqueued(t, re, "main Q"); got != 125 {
+		t.Fatalf("want enqueued 100+20+5, got %d", got)
+	}
+	p, err := findPoint(re, "resource_utime", "resource", "resource-usage")
+	if err != nil || p.Value != 7000 {
+		t.Fatalf("want absolute utime 7000, got %v (err %v)", p.Value, err)
+	}
+	if got := re.restarts.processes[""].Restarts; got != 0 {
+		t.Fatalf("decreasing deltas must not count as restarts, got %d", got)
+	}
+}
+
+func TestDeltaDynstats(t *testing.T) {
+	re := New(WithCounterModes(CounterModes{PerType: map[string]CounterMode{"dynstat": CounterDelta}}))
+	for i, v := range []int{3, 1, 4} {
+		line := stamp(time.Duration(i)*time.Minute) + ` host rsyslogd-pstats: {"name":"msg_per_host","origin":"dynstats.bucket","values":{"host-a":` + strconv.Itoa(v) + `}}`
+		if err := re.handleStatLine([]byte(line)); err != nil {
+			t.Fatalf(handleStatLineFailMsg, err)
+		}
+	}
+	p, err := findPoint(re, "dynstat_msg_per_host", "counter", "host-a")
+	if err != nil || p.Value != 8 {
+		t.Fatalf("want accumulated 8, got %v (err %v)", p.Value, err)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"bufio"
+	"io"
+	"log"
+	"time"
+
+	"github.com/prometheus-community/rsyslog_exporter/internal/input"
+	"github.com/prometheus-community/rsyslog_exporter/internal/rsyslog"
+)
+
+// Source delivers impstats lines to an Exporter. Run sends every line on
+// lines until the input ends or ctx is cancelled.
+type Source = input.Source
+
+// Format is an impstats output format as selected by its "format"
+// parameter.
+type Format = rsyslog.Format
+
+const (
+	FormatAuto              = rsyslog.FormatAuto
+	FormatJSON              = rsyslog.FormatJSON
+	FormatCEE               = rsyslog.FormatCEE
+	FormatJSONElasticsearch = rsyslog.FormatJSONElasticsearch
+	FormatLegacy            = rsyslog.FormatLegacy
+)
+
+// ParseFormat returns the Format named s: "auto" or an impstats format name.
+func ParseFormat(s string) (Format, error) {
+	return rsyslog.ParseFormat(s)
+}
+
+// WithReader makes the exporter read impstats lines from r instead of stdin.
+func WithReader(r io.Reader) Option {
+	return func(e *Exporter) {
+		e.scanner = bufio.NewScanner(r)
+	}
+}
+
+// WithLogger sends the log output of the exporter to l instead of the
+// standard logger.
+func WithLogger(l *log.Logger) Option {
+	return func(e *Exporter) {
+		e.logger = l
+	}
+}
+
+// WithClock makes the exporter take the current time from clock, e.g. to
+// drive expiry and freshness metrics in tests of embedding programs.
+func WithClock(clock func() time.Time) Option {
+	return func(e *Exporter) {
+		e.clock = clock
+	}
+}
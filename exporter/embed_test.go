@@ -0,0 +1,86 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus-community/rsyslog_exporter/internal/input"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestEmbedded(t *testing.T) {
+	in := strings.NewReader(stamp(0) + ` host rsyslogd-pstats: {"name":"main Q","origin":"core.queue","enqueued":20}` + "\n" + "bogus\n")
+	var logs bytes.Buffer
+	clock := func() time.Time { return freshnessStart.Add(time.Minute) }
+	re := New(WithReader(in), WithLogger(log.New(&logs, "", 0)), WithClock(clock), WithFormat(FormatJSON))
+	if err := re.Run(context.Background(), false); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if !strings.Contains(logs.String(), "error handling stats line") {
+		t.Errorf("expected log output on the given logger, got %q", logs.String())
+	}
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(re)
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+	found := map[string]float64{}
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			found[mf.GetName()] = m.GetGauge().GetValue() + m.GetCounter().GetValue()
+		}
+	}
+	if want, got := 20.0, found["rsyslog_queue_enqueued"]; want != got {
+		t.Errorf("want enqueued %v, got %v", want, got)
+	}
+	// the age is measured with the given clock
+	if want, got := 0.0, found["rsyslog_stats_age_seconds"]; want != got {
+		t.Errorf("want age %v, got %v", want, got)
+	}
+}
+
+func TestEmbeddedSourceLogger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.log")
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	var logs bytes.Buffer
+	re := New(WithSource(input.NewFileSource(path, "", time.Millisecond)), WithLogger(log.New(&logs, "", 0)))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_ = re.Run(ctx, true)
+	if !strings.Contains(logs.String(), "Following stats file "+path) {
+		t.Errorf("expected the source to log to the given logger, got %q", logs.String())
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	f, err := ParseFormat("cee")
+	if err != nil || f != FormatCEE {
+		t.Fatalf("want cee, got %v (err %v)", f, err)
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Fatalf("expected error for unknown format")
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus-community/rsyslog_exporter/decoder"
+	"github.com/prometheus-community/rsyslog_exporter/internal/model"
+)
+
+// ExpiryPolicy decides when a series that is no longer reported, e.g. of an
+// action removed by a config reload, is dropped. A series expires after TTL
+// or after MissedIntervals detected impstats intervals without update,
+// whichever comes first. Zero values disable the respective criterion.
+type ExpiryPolicy struct {
+	TTL             time.Duration
+	MissedIntervals int
+}
+
+// maxAge returns how long a series may go without update given the
+// impstats interval, or 0 if it never expires.
+func (p ExpiryPolicy) maxAge(interval time.Duration) time.Duration {
+	var age time.Duration
+	if p.MissedIntervals > 0 && interval > 0 {
+		age = time.Duration(p.MissedIntervals) * interval
+	}
+	if p.TTL > 0 && (age == 0 || p.TTL < age) {
+		age = p.TTL
+	}
+	return age
+}
+
+// Expiry holds the expiry policy for all series and per impstats object
+// type, keyed by type name such as "queue" or "dynstat".
+type Expiry struct {
+	Default ExpiryPolicy
+	PerType map[string]ExpiryPolicy
+}
+
+// enabled reports whether any series may expire at all.
+func (e Expiry) enabled() bool {
+	if e.Default != (ExpiryPolicy{}) {
+		return true
+	}
+	for _, p := range e.PerType {
+		if p != (ExpiryPolicy{}) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e Expiry) policy(objectType string) ExpiryPolicy {
+	if p, ok := e.PerType[objectType]; ok {
+		return p
+	}
+	return e.Default
+}
+
+// ParseExpiryPolicy parses a single policy: a duration such as "10m" sets a
+// TTL, a plain number the missed intervals, and "never" or "0" disables
+// expiry.
+func ParseExpiryPolicy(s string) (ExpiryPolicy, error) {
+	switch s {
+	case "never", "0":
+		return ExpiryPolicy{}, nil
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 0 {
+			return ExpiryPolicy{}, fmt.Errorf("invalid expiry %q: negative intervals", s)
+		}
+		return ExpiryPolicy{MissedIntervals: n}, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return ExpiryPolicy{}, fmt.Errorf("invalid expiry %q: want a duration, a number of intervals or \"never\"", s)
+	}
+	return ExpiryPolicy{TTL: d}, nil
+}
+
+// ParseExpiryOverrides parses per object type policies given as a comma
+// separated list of type=policy pairs, e.g. "dynstat=10m,action=3". Types
+// must name a decoder of r, the registry the exporter decodes with.
+func ParseExpiryOverrides(s string, r *decoder.Registry) (map[string]ExpiryPolicy, error) {
+	overrides := map[string]ExpiryPolicy{}
+	if s == "" {
+		return overrides, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		typ, spec, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid expiry override %q: want type=policy", pair)
+		}
+		if !r.Has(typ) {
+			return nil, fmt.Errorf("invalid expiry override %q: unknown object type %q", pair, typ)
+		}
+		p, err := ParseExpiryPolicy(spec)
+		if err != nil {
+			return nil, err
+		}
+		overrides[typ] = p
+	}
+	return overrides, nil
+}
+
+// WithExpiry drops series that are no longer reported according to e.
+// Without it series are kept forever.
+func WithExpiry(e Expiry) Option {
+	return func(ex *Exporter) {
+		ex.expiry = e
+	}
+}
+
+// expiryCheckInterval is how often stale series are looked for while no
+// impstats interval is known yet.
+const expiryCheckInterval = 15 * time.Second
+
+// expiryRun throttles expiry, which walks every point of the store, to once
+// per impstats interval rather than once per scrape.
+type expiryRun struct {
+	lock sync.Mutex
+	last time.Time
+}
+
+// maybeExpire removes stale series unless expiry is disabled or they were
+// already looked for within the shortest detected impstats interval.
+func (re *Exporter) maybeExpire(at time.Time) {
+	if !re.expiry.enabled() {
+		return
+	}
+	every := re.freshness.shortestStableInterval()
+	if every == 0 {
+		every = expiryCheckInterval
+	}
+	re.expiryRun.lock.Lock()
+	if !re.expiryRun.last.IsZero() && at.Sub(re.expiryRun.last) < every {
+		re.expiryRun.lock.Unlock()
+		return
+	}
+	re.expiryRun.last = at
+	re.expiryRun.lock.Unlock()
+	re.expire(at)
+}
+
+// expire removes stale series from the store. Only points decoded from
+// impstats expire; the exporter's own points are kept. Missed intervals
+// only count once the interval was seen in several batches, so that a
+// single odd batch distance does not expire everything.
+func (re *Exporter) expire(at time.Time) {
+	intervals := map[string]time.Duration{}
+	removed := re.store.Expire(func(p *model.Point, updated time.Time) bool {
+		if p.ObjectType == "" {
+			return false
+		}
+		interval, ok := intervals[p.Host]
+		if !ok {
+			interval = re.freshness.stableInterval(p.Host)
+			intervals[p.Host] = interval
+		}
+		maxAge := re.expiry.policy(p.ObjectType).maxAge(interval)
+		if maxAge == 0 || at.Sub(updated) <= maxAge {
+			return false
+		}
+		re.restarts.forget(p.Key())
+		return true
+	})
+	if removed > 0 {
+		re.logger.Printf("expired %d stale series", removed)
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus-community/rsyslog_exporter/decoder"
+	"github.com/prometheus-community/rsyslog_exporter/internal/model"
+)
+
+func TestParseExpiryPolicy(t *testing.T) {
+	cases := map[string]ExpiryPolicy{
+		"never": {},
+		"0":     {},
+		"3":     {MissedIntervals: 3},
+		"10m":   {TTL: 10 * time.Minute},
+	}
+	for in, want := range cases {
+		got, err := ParseExpiryPolicy(in)
+		if err != nil {
+			t.Fatalf("ParseExpiryPolicy(%q) failed: %v", in, err)
+		}
+		if got != want {
+			t.Errorf("ParseExpiryPolicy(%q): want %+v, got %+v", in, want, got)
+		}
+	}
+	for _, in := range []string{"", "-1", "-5m", "soon"} {
+		if _, err := ParseExpiryPolicy(in); err == nil {
+			t.Errorf("ParseExpiryPolicy(%q): expected error", in)
+		}
+	}
+}
+
+func TestParseExpiryOverrides(t *testing.T) {
+	got, err := ParseExpiryOverrides("dynstat=10m, action=3", decoder.Default())
+	if err != nil {
+		t.Fatalf("ParseExpiryOverrides failed: %v", err)
+	}
+	if want := (ExpiryPolicy{TTL: 10 * time.Minute}); got["dynstat"] != want {
+		t.Errorf("dynstat: want %+v, got %+v", want, got["dynstat"])
+	}
+	if want := (ExpiryPolicy{MissedIntervals: 3}); got["action"] != want {
+		t.Errorf("action: want %+v, got %+v", want, got["action"])
+	}
+	if got, err := ParseExpiryOverrides("", decoder.Default()); err != nil || len(got) != 0 {
+		t.Errorf("expected no overrides for empty input, got %v (err %v)", got, err)
+	}
+	for _, in := range []string{"dynstat", "bogus=10m", "queue=soon"} {
+		if _, err := ParseExpiryOverrides(in, decoder.Default()); err == nil {
+			t.Errorf("ParseExpiryOverrides(%q): expected error", in)
+		}
+	}
+
+	reg := decoder.NewRegistry()
+	reg.MustRegister(decoder.New("imfoo", decoder.MatchOrigin("imfoo"), nil))
+	if _, err := ParseExpiryOverrides("imfoo=10m", reg); err != nil {
+		t.Errorf("expected type of a custom registry to be accepted: %v", err)
+	}
+	if _, err := ParseExpiryOverrides("imfoo=10m", decoder.Default()); err == nil {
+		t.Errorf("expected type missing from the registry to be rejected")
+	}
+}
+
+func TestExpiryPolicyMaxAge(t *testing.T) {
+	cases := []struct {
+		policy   ExpiryPolicy
+		interval time.Duration
+		want     time.Duration
+	}{
+		{ExpiryPolicy{}, time.Minute, 0},
+		{ExpiryPolicy{TTL: time.Hour}, time.Minute, time.Hour},
+		{ExpiryPolicy{MissedIntervals: 3}, time.Minute, 3 * time.Minute},
+		// interval not detected yet
+		{ExpiryPolicy{MissedIntervals: 3}, 0, 0},
+		// whichever comes first
+		{ExpiryPolicy{TTL: time.Hour, MissedIntervals: 3}, time.Minute, 3 * time.Minute},
+		{ExpiryPolicy{TTL: 2 * time.Minute, MissedIntervals: 3}, time.Minute, 2 * time.Minute},
+	}
+	for _, c := range cases {
+		if got := c.policy.maxAge(c.interval); got != c.want {
+			t.Errorf("%+v with interval %v: want %v, got %v", c.policy, c.interval, c.want, got)
+		}
+	}
+}
+
+func TestExpireStaleSeries(t *testing.T) {
+	origNow := now
+	defer func() { now = origNow }()
+	at := freshnessStart
+	now = func() time.Time { return at }
+
+	re := New(WithExpiry(Expiry{
+		Default: ExpiryPolicy{MissedIntervals: 3},
+		PerType: map[string]ExpiryPolicy{"dynstat": {}},
+	}))
+	own := &model.Point{Name: "stats_line_errors", Type: model.Counter}
+	_ = re.store.Set(own)
+
+	queue := func(name string) []byte {
+		return []byte(stamp(at.Sub(freshnessStart)) + ` host rsyslogd-pstats: {"name":"` + name + `","enqueued":1}`)
+	}
+	dynstat := []byte(stamp(0) + ` host rsyslogd-pstats: {"name":"global","origin":"dynstats","values":{"msg_per_host.ops_overflow":1}}`)
+	for _, l := range [][]byte{queue("old Q"), queue("main Q"), dynstat} {
+		if err := re.handleStatLine(l); err != nil {
+			t.Fatalf(handleStatLineFailMsg, err)
+		}
+	}
+	// "old Q" disappears with the next batches, one minute apart
+	for i := 0; i < minStableGaps; i++ {
+		at = at.Add(time.Minute)
+		if err := re.handleStatLine(queue("main Q")); err != nil {
+			t.Fatalf(handleStatLineFailMsg, err)
+		}
+	}
+
+	// three missed intervals are not exceeded yet
+	re.expire(at)
+	if _, err := findPoint(re, "queue_enqueued", "queue", "old Q"); err != nil {
+		t.Fatalf("expected old queue to be kept within three intervals: %v", err)
+	}
+
+	at = at.Add(time.Second)
+	re.expire(at)
+	if _, err := findPoint(re, "queue_enqueued", "queue", "old Q"); err != model.ErrPointNotFound {
+		t.Fatalf("expected old queue to expire, got %v", err)
+	}
+	if _, err := findPoint(re, "queue_enqueued", "queue", "main Q"); err != nil {
+		t.Fatalf("expected main queue to be kept: %v", err)
+	}
+	if _, err := findPoint(re, "dynstat_global", "counter", "msg_per_host.ops_overflow"); err != nil {
+		t.Fatalf("expected dynstat override to keep the series: %v", err)
+	}
+	if _, err := re.store.Get(own.Key()); err != nil {
+		t.Fatalf("expected the exporter's own point to be kept: %v", err)
+	}
+}
+
+func TestExpireNeedsStableInterval(t *testing.T) {
+	origNow := now
+	defer func() { now = origNow }()
+	now = func() time.Time { return freshnessStart }
+
+	re := New(WithExpiry(Expiry{Default: ExpiryPolicy{MissedIntervals: 1}}))
+	// one emission split across a second boundary looks like a 1s interval
+	for _, l := range []string{
+		stamp(0) + ` host rsyslogd-pstats: {"name":"old Q","enqueued":1}`,
+		stamp(time.Second) + ` host rsyslogd-pstats: {"name":"main Q","enqueued":1}`,
+	} {
+		if err := re.handleStatLine([]byte(l)); err != nil {
+			t.Fatalf(handleStatLineFailMsg, err)
+		}
+	}
+	re.expire(freshnessStart.Add(time.Minute))
+	if _, err := findPoint(re, "queue_enqueued", "queue", "old Q"); err != nil {
+		t.Fatalf("expected no expiry by an interval seen once: %v", err)
+	}
+}
+
+func TestMaybeExpireThrottled(t *testing.T) {
+	origNow := now
+	defer func() { now = origNow }()
+	now = func() time.Time { return freshnessStart }
+
+	line := []byte(stamp(0) + ` host rsyslogd-pstats: {"name":"old Q","enqueued":1}`)
+	re := New()
+	if err := re.handleStatLine(line); err != nil {
+		t.Fatalf(handleStatLineFailMsg, err)
+	}
+	re.maybeExpire(freshnessStart.Add(time.Hour))
+	if _, err := findPoint(re, "queue_enqueued", "queue", "old Q"); err != nil {
+		t.Fatalf("expected no expiry without policy: %v", err)
+	}
+
+	re = New(WithExpiry(Expiry{Default: ExpiryPolicy{TTL: time.Minute}}))
+	at := freshnessStart.Add(2 * time.Minute)
+	re.maybeExpire(at)
+	if err := re.handleStatLine(line); err != nil {
+		t.Fatalf(handleStatLineFailMsg, err)
+	}
+	// the store was just walked, so the stale queue survives the next scrape
+	re.maybeExpire(at.Add(time.Second))
+	if _, err := findPoint(re, "queue_enqueued", "queue", "old Q"); err != nil {
+		t.Fatalf("expected expiry to be throttled: %v", err)
+	}
+	re.maybeExpire(at.Add(expiryCheckInterval))
+	if _, err := findPoint(re, "queue_enqueued", "queue", "old Q"); err != model.ErrPointNotFound {
+		t.Fatalf("expected old queue to expire, got %v", err)
+	}
+}
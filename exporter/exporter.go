@@ -0,0 +1,369 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package exporter collects rsyslog impstats metrics for Prometheus. An
+// Exporter reads impstats lines from stdin, an io.Reader or a Source and is
+// a prometheus.Collector, so programs may embed it next to their own
+// collectors.
+package exporter
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/prometheus-community/rsyslog_exporter/decoder"
+	"github.com/prometheus-community/rsyslog_exporter/internal/input"
+	"github.com/prometheus-community/rsyslog_exporter/internal/model"
+	"github.com/prometheus-community/rsyslog_exporter/internal/rsyslog"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Exporter collects and exposes rsyslog impstats metrics.
+type Exporter struct {
+	scanner *bufio.Scanner
+	// source replaces scanner as the input when set.
+	source Source
+	// format is the impstats output format of incoming lines.
+	format rsyslog.Format
+	// framer splits incoming lines into header fields and payload.
+	framer Framer
+	// hostLabel labels every point with the hostname of its line.
+	hostLabel bool
+	// decoders selects the decoder of every impstats object.
+	decoders *decoder.Registry
+	// parser splits every stats line into its fields once, for both
+	// classification and decoding. It is only used by the goroutine
+	// handling lines.
+	parser decoder.Parser
+	// generic decodes objects of unknown type with the generic decoder.
+	generic bool
+	// debug logs how every stats line is classified.
+	debug bool
+	// freshness tracks stats timestamps and the impstats interval.
+	freshness *freshness
+	// expiry decides when series no longer reported are dropped.
+	expiry Expiry
+	// expiryRun throttles expiry of stale series to once per interval.
+	expiryRun expiryRun
+	// staged holds the points of open bracketed impstats emissions per
+	// host. It is only used by the goroutine handling lines.
+	staged map[string][]*model.Point
+	// restarts detects rsyslog restarts and keeps counters monotonic.
+	restarts *restartTracker
+	// persist configures the snapshot file of the store, if any.
+	persist persistence
+	// logger receives all log output.
+	logger *log.Logger
+	// clock returns the current time.
+	clock func() time.Time
+	// scrape caches the metrics rendered from the store.
+	scrape scrapeCache
+	// store holds the latest point of every series.
+	store *model.Store
+}
+
+// Option configures an Exporter.
+type Option func(*Exporter)
+
+// WithSource makes the exporter read impstats lines from src instead of stdin.
+func WithSource(src Source) Option {
+	return func(e *Exporter) {
+		e.source = src
+	}
+}
+
+// WithFormat sets the impstats output format of incoming lines. The default,
+// rsyslog.FormatAuto, detects the format of each line.
+func WithFormat(f rsyslog.Format) Option {
+	return func(e *Exporter) {
+		e.format = f
+	}
+}
+
+// WithFraming sets how incoming lines are split into header fields and
+// impstats payload. The default is FramingColumns.
+func WithFraming(f Framer) Option {
+	return func(e *Exporter) {
+		e.framer = f
+	}
+}
+
+// WithHostLabel adds a "host" label holding the hostname of the reporting
+// rsyslog instance to every point, so that one exporter can aggregate the
+// stats of several hosts without their series overwriting each other.
+func WithHostLabel(enabled bool) Option {
+	return func(e *Exporter) {
+		e.hostLabel = enabled
+	}
+}
+
+// WithGenericDecoder exports every numeric field of impstats objects of
+// unknown type as rsyslog_stat_value, instead of rejecting them as errors.
+func WithGenericDecoder(enabled bool) Option {
+	return func(e *Exporter) {
+		e.generic = enabled
+	}
+}
+
+// WithDebug logs the type of every stats line and the rule that matched it.
+func WithDebug(enabled bool) Option {
+	return func(e *Exporter) {
+		e.debug = enabled
+	}
+}
+
+// WithRegistry decodes impstats objects with the decoders of r instead of
+// those of decoder.Default().
+func WithRegistry(r *decoder.Registry) Option {
+	return func(e *Exporter) {
+		e.decoders = r
+	}
+}
+
+func newExporter(opts ...Option) *Exporter {
+	e := &Exporter{
+		scanner:   bufio.NewScanner(os.Stdin),
+		framer:    columnsFramer{},
+		decoders:  decoder.Default(),
+		freshness: newFreshness(),
+		staged:    make(map[string][]*model.Point),
+		restarts:  newRestartTracker(),
+		logger:    log.Default(),
+		clock:     func() time.Time { return now() },
+		store:     model.NewStore(),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if e.source != nil {
+		input.SetLogger(e.source, e.logger)
+	}
+	if f, ok := e.framer.(clockFramer); ok {
+		e.framer = f.withClock(e.clock)
+	}
+	return e
+}
+
+// New returns an initialized Exporter reading from stdin unless configured
+// otherwise by opts.
+func New(opts ...Option) *Exporter {
+	return newExporter(opts...)
+}
+
+func (re *Exporter) handleStatLine(rawbuf []byte) error {
+	line, err := re.framer.Frame(rawbuf)
+	if err != nil {
+		return err
+	}
+	return re.handleLine(line)
+}
+
+// handleLine decodes the payload of a framed stats line into the store.
+func (re *Exporter) handleLine(line Line) error {
+	host := re.host(line)
+	received := re.clock()
+	if isBracketMarker(line.Payload) {
+		re.handleBracketMarker(host, line.Payload, received)
+		return nil
+	}
+	buf, err := rsyslog.Normalize(line.Payload, re.format)
+	if err != nil {
+		return err
+	}
+	// a line that is not JSON is still classified by its content, and
+	// fails to decode below
+	obj, _ := re.parser.Parse(buf)
+	dec, rule := re.decoders.Lookup(obj)
+	if dec == nil && re.generic {
+		dec, rule = decoder.Generic(), "generic decoder"
+	}
+	if dec == nil {
+		return fmt.Errorf("unknown pstat type of object %q with origin %q (%s)", obj.Name, obj.Origin, rule)
+	}
+	if re.debug {
+		re.logger.Printf("classified stats line as %s by %s", dec.Name(), rule)
+	}
+	points, err := dec.Decode(obj)
+	if err != nil {
+		return err
+	}
+	points = append(points, objectInfo(obj, dec))
+	for _, p := range points {
+		p.Host = host
+		p.ObjectType = dec.Name()
+		// every series carries the origin, empty for objects of rsyslog
+		// versions without one, so that its label set does not vary
+		if !p.HasLabel(model.OriginLabelName) {
+			p.Labels = append(p.Labels, model.Label{Name: model.OriginLabelName, Value: obj.Origin})
+		}
+	}
+	ts := lineTime(line.Timestamp, received)
+	restarted, adjusted := re.restarts.track(host, points, ts)
+	if restarted {
+		re.logger.Printf("detected restart of rsyslog on host %q", host)
+	}
+	// points adjusted for the restart replace those stored before
+	points = append(adjusted, points...)
+	if !re.stage(host, points) {
+		re.store.SetBatch(points, received)
+	}
+	re.freshness.observe(host, dec.Name(), ts, received)
+	return nil
+}
+
+// unknownHost labels points of lines without hostname when the host label
+// is enabled.
+const unknownHost = "unknown"
+
+// host returns the host label value for points of line, or "" if points
+// are not labelled by host. The hostname is sent by whoever reaches the
+// input, so it is sanitized before it keys any state.
+func (re *Exporter) host(line Line) string {
+	if !re.hostLabel {
+		return ""
+	}
+	if line.Hostname == "" || line.Hostname == "-" {
+		return unknownHost
+	}
+	return model.SanitizeLabelValue(line.Hostname)
+}
+
+// test hooks used by unit tests to simulate concurrent map mutation.
+var (
+	// The hooks are intentionally set to no-op functions in production code so
+	// callers don't need to perform nil checks on the hot code path. Tests may
+	// override these variables to inject race/mutation scenarios (for example
+	// deleting a key before a snapshot). Keep them as non-nil empty
+	// functions to keep runtime behavior simple and efficient.
+	describeBeforeSnapshotHook = func() {
+		// intentionally empty: test hook to simulate concurrent mutation
+	}
+	collectBeforeSnapshotHook = func() {
+		// intentionally empty: test hook to simulate concurrent mutation
+	}
+)
+
+// Describe sends the description of currently known metrics collected
+// by this Collector to the provided channel. Note that this implementation
+// does not necessarily send the "super-set of all possible descriptors" as
+// defined by the Collector interface spec, depending on the timing of when
+// it is called. The rsyslog exporter does not know all possible metrics
+// it will export until the first full batch of rsyslog impstats messages
+// is received from its input. This is ok for now.
+func (re *Exporter) Describe(ch chan<- *prometheus.Desc) {
+	ch <- prometheus.NewDesc(
+		prometheus.BuildFQName("", "rsyslog", "scrapes"),
+		"times exporter has been scraped",
+		nil, nil,
+	)
+
+	re.freshness.describe(ch)
+	re.restarts.describe(ch)
+
+	describeBeforeSnapshotHook()
+	_, descs, _ := re.scrape.render(re.store, re.logger)
+	for _, d := range descs {
+		ch <- d
+	}
+	ch <- skippedSeriesDesc
+}
+
+// Collect is called by Prometheus when collecting metrics.
+func (re *Exporter) Collect(ch chan<- prometheus.Metric) {
+	at := re.clock()
+	re.maybeExpire(at)
+	skipped := re.freshness.collect(ch, at)
+	skipped += re.restarts.collect(ch)
+
+	// a single snapshot keeps related series consistent, e.g. when a batch
+	// is committed during the scrape
+	collectBeforeSnapshotHook()
+	metrics, _, n := re.scrape.render(re.store, re.logger)
+	for _, m := range metrics {
+		ch <- m
+	}
+	ch <- prometheus.MustNewConstMetric(skippedSeriesDesc, prometheus.GaugeValue, float64(skipped+n))
+}
+
+func (re *Exporter) runLoop(ctx context.Context, silent bool) error {
+	errorPoint := &model.Point{
+		Name:        "stats_line_errors",
+		Type:        model.Counter,
+		Description: "Counts errors during stats line handling",
+	}
+	// continue counting from a restored snapshot
+	if p, err := re.store.Get(errorPoint.Key()); err == nil {
+		errorPoint.Value = p.Value
+	}
+	// nolint:errcheck
+	re.store.SetAt(errorPoint, re.clock())
+	// read lines in a goroutine and receive them on a channel so we can
+	// select between incoming lines and context cancellation.
+	src := re.source
+	if src == nil {
+		src = input.NewScannerSource(re.scanner)
+	}
+	lines := make(chan []byte)
+	errC := make(chan error, 1)
+
+	go func() {
+		defer close(lines)
+		errC <- src.Run(ctx, lines)
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			re.logger.Print("runLoop: context canceled, returning")
+			return ctx.Err()
+		case line, ok := <-lines:
+			if !ok {
+				// channel closed = input ended
+				if err := <-errC; err != nil {
+					re.logger.Printf("error reading input: %v", err)
+					return err
+				}
+				re.logger.Print("input ended, returning from run")
+				return nil
+			}
+			err := re.handleStatLine(line)
+			if err != nil {
+				// replace rather than mutate the stored point, which a
+				// concurrent scrape may be reading
+				next := *errorPoint
+				next.Value++
+				errorPoint = &next
+				_ = re.store.SetAt(errorPoint, re.clock())
+				if !silent {
+					re.logger.Printf("error handling stats line: %v, line was: %s", err, line)
+				}
+			}
+			input.ReleaseLine(line)
+		}
+	}
+}
+
+// Run reads and handles stats lines until the input ends or ctx is done,
+// and returns the error that ended it, if any. silent suppresses logging of
+// lines that fail to be handled. Embedding programs decide themselves
+// whether an error is fatal.
+func (re *Exporter) Run(ctx context.Context, silent bool) error {
+	if re.persist.path != "" {
+		return re.runPersisted(ctx, silent)
+	}
+	return re.runLoop(ctx, silent)
+}
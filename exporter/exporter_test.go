@@ -18,11 +18,15 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"log"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/prometheus-community/rsyslog_exporter/decoder"
 	"github.com/prometheus-community/rsyslog_exporter/internal/model"
+	"github.com/prometheus-community/rsyslog_exporter/internal/rsyslog"
 	th "github.com/prometheus-community/rsyslog_exporter/internal/testhelpers"
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -70,9 +74,9 @@ func TestHandleStatLineResource(t *testing.T) {
 		t.Fatalf(handleStatLineFailMsg, err)
 	}
 
-	// verify store has the expected point key (name.label)
-	key := "resource_utime.myres"
-	p, err := re.Get(key)
+	// verify store has the expected point key (name{label="value"})
+	key := `resource_utime{resource="myres",origin=""}`
+	p, err := re.store.Get(key)
 	if err != nil {
 		t.Fatalf("expected point for key %s: %v", key, err)
 	}
@@ -80,7 +84,7 @@ func TestHandleStatLineResource(t *testing.T) {
 		t.Fatalf("unexpected point name: %s", p.Name)
 	}
 	if p.Value != 42 {
-		t.Fatalf("unexpected value: %d", p.Value)
+		t.Fatalf("unexpected value: %v", p.Value)
 	}
 	if p.Type != model.Counter {
 		t.Fatalf("unexpected type: %v", p.Type)
@@ -93,12 +97,12 @@ func testHelper(t *testing.T, line []byte, testCase []*testUnit) {
 		t.Fatalf(handleStatLineFailMsg, err)
 	}
 
-	for _, k := range exporter.Keys() {
+	for _, k := range exporter.store.Keys() {
 		t.Logf("have key: '%s'", k)
 	}
 
 	for _, item := range testCase {
-		p, err := exporter.Get(item.key())
+		p, err := item.find(exporter)
 		if err != nil {
 			t.Error(err)
 		}
@@ -113,7 +117,7 @@ func testHelper(t *testing.T, line []byte, testCase []*testUnit) {
 	}
 
 	for _, item := range testCase {
-		p, err := exporter.Get(item.key())
+		p, err := item.find(exporter)
 		if err != nil {
 			t.Error(err)
 		}
@@ -138,11 +142,40 @@ func testHelper(t *testing.T, line []byte, testCase []*testUnit) {
 type testUnit struct {
 	Name       string
 	Val        float64
+	LabelName  string
 	LabelValue string
+	// Origin is the origin of the object of the line, if any.
+	Origin string
 }
 
-func (u *testUnit) key() string {
-	return fmt.Sprintf("%s.%s", u.Name, u.LabelValue)
+// find returns the stored point of u.
+func (u *testUnit) find(re *Exporter) (*model.Point, error) {
+	return findPoint(re, u.Name, u.LabelName, u.LabelValue, model.OriginLabelName, u.Origin)
+}
+
+// findPoint returns the stored point of name with the given label name and
+// value pairs, whatever its other labels. The host label matches
+// Point.Host.
+func findPoint(re *Exporter, name string, labels ...string) (*model.Point, error) {
+	for _, p := range re.store.Snapshot() {
+		if p.Name == name && hasLabels(p, labels) {
+			return p, nil
+		}
+	}
+	return &model.Point{}, model.ErrPointNotFound
+}
+
+func hasLabels(p *model.Point, labels []string) bool {
+	for i := 0; i+1 < len(labels); i += 2 {
+		value := p.Label(labels[i])
+		if labels[i] == model.HostLabelName {
+			value = p.Host
+		}
+		if value != labels[i+1] {
+			return false
+		}
+	}
+	return true
 }
 
 func TestHandleLineWithAction(t *testing.T) {
@@ -150,26 +183,31 @@ func TestHandleLineWithAction(t *testing.T) {
 		{
 			Name:       "action_processed",
 			Val:        100000,
+			LabelName:  "action",
 			LabelValue: th.TestAction,
 		},
 		{
 			Name:       "action_failed",
 			Val:        2,
+			LabelName:  "action",
 			LabelValue: th.TestAction,
 		},
 		{
 			Name:       "action_suspended",
 			Val:        1,
+			LabelName:  "action",
 			LabelValue: th.TestAction,
 		},
 		{
 			Name:       "action_suspended_duration",
 			Val:        1000,
+			LabelName:  "action",
 			LabelValue: th.TestAction,
 		},
 		{
 			Name:       "action_resumed",
 			Val:        1,
+			LabelName:  "action",
 			LabelValue: th.TestAction,
 		},
 	}
@@ -183,46 +221,55 @@ func TestHandleLineWithResource(t *testing.T) {
 		{
 			Name:       "resource_utime",
 			Val:        10,
+			LabelName:  "resource",
 			LabelValue: th.ResourceUsage,
 		},
 		{
 			Name:       "resource_stime",
 			Val:        20,
+			LabelName:  "resource",
 			LabelValue: th.ResourceUsage,
 		},
 		{
 			Name:       "resource_maxrss",
 			Val:        30,
+			LabelName:  "resource",
 			LabelValue: th.ResourceUsage,
 		},
 		{
 			Name:       "resource_minflt",
 			Val:        40,
+			LabelName:  "resource",
 			LabelValue: th.ResourceUsage,
 		},
 		{
 			Name:       "resource_majflt",
 			Val:        50,
+			LabelName:  "resource",
 			LabelValue: th.ResourceUsage,
 		},
 		{
 			Name:       "resource_inblock",
 			Val:        60,
+			LabelName:  "resource",
 			LabelValue: th.ResourceUsage,
 		},
 		{
 			Name:       "resource_oublock",
 			Val:        70,
+			LabelName:  "resource",
 			LabelValue: th.ResourceUsage,
 		},
 		{
 			Name:       "resource_nvcsw",
 			Val:        80,
+			LabelName:  "resource",
 			LabelValue: th.ResourceUsage,
 		},
 		{
 			Name:       "resource_nivcsw",
 			Val:        90,
+			LabelName:  "resource",
 			LabelValue: th.ResourceUsage,
 		},
 	}
@@ -236,7 +283,9 @@ func TestHandleLineWithInput(t *testing.T) {
 		{
 			Name:       "input_submitted",
 			Val:        1000,
+			LabelName:  "input",
 			LabelValue: th.TestInput,
+			Origin:     "imuxsock",
 		},
 	}
 
@@ -249,31 +298,37 @@ func TestHandleLineWithQueue(t *testing.T) {
 		{
 			Name:       "queue_size",
 			Val:        10,
+			LabelName:  "queue",
 			LabelValue: th.MainQueueValue,
 		},
 		{
 			Name:       "queue_enqueued",
 			Val:        20,
+			LabelName:  "queue",
 			LabelValue: th.MainQueueValue,
 		},
 		{
 			Name:       "queue_full",
 			Val:        30,
+			LabelName:  "queue",
 			LabelValue: th.MainQueueValue,
 		},
 		{
 			Name:       "queue_discarded_full",
 			Val:        40,
+			LabelName:  "queue",
 			LabelValue: th.MainQueueValue,
 		},
 		{
 			Name:       "queue_discarded_not_full",
 			Val:        50,
+			LabelName:  "queue",
 			LabelValue: th.MainQueueValue,
 		},
 		{
 			Name:       "queue_max_size",
 			Val:        60,
+			LabelName:  "queue",
 			LabelValue: th.MainQueueValue,
 		},
 	}
@@ -287,27 +342,37 @@ func TestHandleLineWithGlobal(t *testing.T) {
 		{
 			Name:       "dynstat_global",
 			Val:        1,
+			LabelName:  "counter",
 			LabelValue: th.MsgPerHostOpsOverflow,
+			Origin:     "dynstats",
 		},
 		{
 			Name:       "dynstat_global",
 			Val:        3,
+			LabelName:  "counter",
 			LabelValue: th.MsgPerHostNewMetricAdd,
+			Origin:     "dynstats",
 		},
 		{
 			Name:       "dynstat_global",
 			Val:        0,
+			LabelName:  "counter",
 			LabelValue: th.MsgPerHostNoMetric,
+			Origin:     "dynstats",
 		},
 		{
 			Name:       "dynstat_global",
 			Val:        0,
+			LabelName:  "counter",
 			LabelValue: th.MsgPerHostMetricsPurged,
+			Origin:     "dynstats",
 		},
 		{
 			Name:       "dynstat_global",
 			Val:        0,
+			LabelName:  "counter",
 			LabelValue: th.MsgPerHostOpsIgnored,
+			Origin:     "dynstats",
 		},
 	}
 
@@ -321,22 +386,30 @@ func TestHandleLineWithDynafileCache(t *testing.T) {
 		{
 			Name:       "dynafile_cache_requests",
 			Val:        412044,
+			LabelName:  "cache",
 			LabelValue: "cluster",
+			Origin:     "omfile",
 		},
 		{
 			Name:       "dynafile_cache_level0",
 			Val:        294002,
+			LabelName:  "cache",
 			LabelValue: "cluster",
+			Origin:     "omfile",
 		},
 		{
 			Name:       "dynafile_cache_missed",
 			Val:        210,
+			LabelName:  "cache",
 			LabelValue: "cluster",
+			Origin:     "omfile",
 		},
 		{
 			Name:       "dynafile_cache_evicted",
 			Val:        14,
+			LabelName:  "cache",
 			LabelValue: "cluster",
+			Origin:     "omfile",
 		},
 	}
 
@@ -344,6 +417,213 @@ func TestHandleLineWithDynafileCache(t *testing.T) {
 	testHelper(t, dynafileCacheLog, tests)
 }
 
+func TestHandleLineFormats(t *testing.T) {
+	tests := []*testUnit{
+		{
+			Name:       "queue_enqueued",
+			Val:        20,
+			LabelName:  "queue",
+			LabelValue: th.MainQueueValue,
+			Origin:     "core.queue",
+		},
+		{
+			Name:       "queue_discarded_full",
+			Val:        40,
+			LabelName:  "queue",
+			LabelValue: th.MainQueueValue,
+			Origin:     "core.queue",
+		},
+	}
+
+	const prefix = `2017-08-30T08:10:04.786350+00:00 some-node.example.org rsyslogd-pstats: `
+	cases := map[string]string{
+		"cee":                `@cee: {"name":"` + th.MainQueueValue + `","origin":"core.queue","enqueued":20,"discarded.full":40}`,
+		"json-elasticsearch": `{"name":"` + th.MainQueueValue + `","origin":"core.queue","enqueued":20,"discarded!full":40}`,
+		"legacy":             th.MainQueueValue + `: origin=core.queue size=10 enqueued=20 discarded.full=40`,
+	}
+	for name, payload := range cases {
+		t.Run(name, func(t *testing.T) {
+			testHelper(t, []byte(prefix+payload), tests)
+		})
+	}
+}
+
+func TestHandleLineExplicitFormat(t *testing.T) {
+	re := New(WithFormat(rsyslog.FormatLegacy))
+	// JSON is not valid legacy input once the format is fixed
+	if re.handleStatLine([]byte(`c1 c2 c3 {"name":"x","enqueued":1}`)) == nil {
+		t.Fatalf("expected error for JSON input with legacy format")
+	}
+	if err := re.handleStatLine([]byte(`c1 c2 c3 main Q: origin=core.queue enqueued=1`)); err != nil {
+		t.Fatalf(handleStatLineFailMsg, err)
+	}
+}
+
+func TestHandleLineHostLabel(t *testing.T) {
+	re := New(WithHostLabel(true))
+	payload := ` rsyslogd-pstats: {"name":"` + th.MainQueueValue + `","enqueued":20}`
+	for _, l := range []string{"ts relay-03" + payload, "ts relay-04" + payload, "ts -" + payload} {
+		if err := re.handleStatLine([]byte(l)); err != nil {
+			t.Fatalf(handleStatLineFailMsg, err)
+		}
+	}
+	for _, host := range []string{"relay-03", "relay-04", unknownHost} {
+		p, err := findPoint(re, "queue_enqueued", "queue", th.MainQueueValue, model.HostLabelName, host)
+		if err != nil {
+			t.Fatalf("expected point for host %s: %v", host, err)
+		}
+		th.AssertEqString(t, "host", host, p.Host)
+	}
+
+	// every host also gets last timestamp, age, restarts and its object
+	// count, next to the skipped series count; without restarts there is
+	// no start time
+	want := len(re.store.Keys()) + 4*3 + 1
+	ch := make(chan prometheus.Metric, want)
+	re.Collect(ch)
+	if len(ch) != want {
+		t.Fatalf("expected %d metrics, got %d", want, len(ch))
+	}
+}
+
+func TestHandleLineOrigin(t *testing.T) {
+	re := New()
+	line := `ts host rsyslogd-pstats: {"name":"fwd","origin":"core.action","processed":7}`
+	if err := re.handleStatLine([]byte(line)); err != nil {
+		t.Fatalf(handleStatLineFailMsg, err)
+	}
+	p, err := findPoint(re, "action_processed", "action", "fwd")
+	if err != nil {
+		t.Fatalf("expected action labelled by origin: %v", err)
+	}
+	th.AssertEqString(t, "label names", "[action module action_index origin]", fmt.Sprint(p.PromLabelNames()))
+	th.AssertEqString(t, "origin", "core.action", p.Label(model.OriginLabelName))
+}
+
+func TestHandleLineUnknownType(t *testing.T) {
+	re := New()
+	line := `ts host rsyslogd-pstats: {"name":"foo0","origin":"imfoo","opened":3}`
+	err := re.handleStatLine([]byte(line))
+	if err == nil {
+		t.Fatalf("expected error for object of unknown origin")
+	}
+	want := `unknown pstat type of object "foo0" with origin "imfoo" (unknown origin "imfoo")`
+	th.AssertEqString(t, "error", want, err.Error())
+}
+
+func TestHandleLineDiskAssistedQueue(t *testing.T) {
+	re := New()
+	for _, line := range []string{
+		`ts host rsyslogd-pstats: {"name":"main Q","origin":"core.queue","size":100,"enqueued":500}`,
+		`ts host rsyslogd-pstats: {"name":"main Q[DA]","origin":"core.queue","size":40,"enqueued":90}`,
+	} {
+		if err := re.handleStatLine([]byte(line)); err != nil {
+			t.Fatalf(handleStatLineFailMsg, err)
+		}
+	}
+	for kind, want := range map[string]float64{"primary": 500, "disk_assisted": 90} {
+		p, err := findPoint(re, "queue_enqueued", "queue", "main Q", "queue_kind", kind)
+		if err != nil {
+			t.Fatalf("expected %s main queue: %v", kind, err)
+		}
+		th.AssertEqFloat(t, kind+" enqueued", want, p.Value)
+	}
+	p, err := findPoint(re, "queue_disk_spooled", "queue", "main Q")
+	if err != nil {
+		t.Fatalf("expected spooled messages of the main queue: %v", err)
+	}
+	th.AssertEqFloat(t, "spooled", 40, p.Value)
+	p, err = findPoint(re, "queue_disk_assisted_active", "queue", "main Q")
+	if err != nil {
+		t.Fatalf("expected disk-assisted state of the main queue: %v", err)
+	}
+	th.AssertEqFloat(t, "active", 1, p.Value)
+}
+
+func TestObjectInventory(t *testing.T) {
+	re := New(WithHostLabel(true))
+	for _, l := range []string{
+		`ts relay-01 rsyslogd-pstats: {"name":"main Q","origin":"core.queue","enqueued":1}`,
+		`ts relay-01 rsyslogd-pstats: {"name":"action 0 queue","origin":"core.queue","enqueued":1}`,
+		`ts relay-01 rsyslogd-pstats: {"name":"fwd","origin":"core.action","processed":1}`,
+		`ts relay-02 rsyslogd-pstats: {"name":"main Q","origin":"core.queue","enqueued":1}`,
+	} {
+		if err := re.handleStatLine([]byte(l)); err != nil {
+			t.Fatalf(handleStatLineFailMsg, err)
+		}
+	}
+	key := (&model.Point{Name: "object_info", Labels: []model.Label{
+		{Name: "type", Value: "action"},
+		{Name: "name", Value: "fwd"},
+		{Name: "origin", Value: "core.action"},
+	}, Host: "relay-01"}).Key()
+	p, err := re.store.Get(key)
+	if err != nil {
+		t.Fatalf("expected object info %s: %v", key, err)
+	}
+	th.AssertEqFloat(t, "info value", 1, p.PromValue())
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(re)
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+	counts := make(map[string]float64)
+	for _, f := range families {
+		if f.GetName() != "rsyslog_objects" {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			var typ, host string
+			for _, l := range m.GetLabel() {
+				switch l.GetName() {
+				case "type":
+					typ = l.GetValue()
+				case "host":
+					host = l.GetValue()
+				}
+			}
+			counts[host+"/"+typ] = m.GetGauge().GetValue()
+		}
+	}
+	th.AssertEqString(t, "counts", "map[relay-01/action:1 relay-01/queue:2 relay-02/queue:1]", fmt.Sprint(counts))
+}
+
+func TestHandleLineDebug(t *testing.T) {
+	var buf bytes.Buffer
+	re := New(WithDebug(true), WithLogger(log.New(&buf, "", 0)))
+	line := `ts host rsyslogd-pstats: {"name":"submitted","origin":"core.queue","enqueued":20}`
+	if err := re.handleStatLine([]byte(line)); err != nil {
+		t.Fatalf(handleStatLineFailMsg, err)
+	}
+	if want := `classified stats line as queue by origin "core.queue"`; !strings.Contains(buf.String(), want) {
+		t.Fatalf("expected %q in log output: %s", want, buf.String())
+	}
+}
+
+func TestHandleLineCustomDecoder(t *testing.T) {
+	reg := decoder.NewRegistry()
+	reg.MustRegister(decoder.New("imfoo", decoder.MatchOrigin("imfoo"), func(obj decoder.Object) ([]*model.Point, error) {
+		return []*model.Point{{Name: "imfoo_opened", Type: model.Counter, Value: obj.Number("opened")}}, nil
+	}))
+	re := New(WithRegistry(reg))
+	line := `ts host rsyslogd-pstats: {"name":"foo0","origin":"imfoo","opened":3}`
+	if err := re.handleStatLine([]byte(line)); err != nil {
+		t.Fatalf(handleStatLineFailMsg, err)
+	}
+	p, err := findPoint(re, "imfoo_opened", model.OriginLabelName, "imfoo")
+	if err != nil {
+		t.Fatalf("expected point of custom decoder: %v", err)
+	}
+	th.AssertEqString(t, "object type", "imfoo", p.ObjectType)
+
+	// the default registry does not know imfoo
+	if New().handleStatLine([]byte(line)) == nil {
+		t.Fatalf("expected error without the custom decoder")
+	}
+}
+
 func TestHandleUnknown(t *testing.T) {
 	unknownLog := []byte(`2017-08-30T08:10:04.786350+00:00 some-node.example.org rsyslogd-pstats: {"a":"b"}`)
 
@@ -353,7 +633,30 @@ func TestHandleUnknown(t *testing.T) {
 		t.Logf("handleStatLine returned expected error for unknown log: %v", err)
 	}
 
-	if want, got := 0, len(exporter.Keys()); want != got {
+	if want, got := 0, len(exporter.store.Keys()); want != got {
+		t.Errorf(th.ExpectedActualIntFmt, want, got)
+	}
+}
+
+func TestHandleUnknownGeneric(t *testing.T) {
+	line := []byte(`2017-08-30T08:10:04.786350+00:00 some-node.example.org rsyslogd-pstats: {"name":"imfoo","origin":"imfoo","opened":3,"state":"running"}`)
+
+	re := New(WithGenericDecoder(true))
+	if err := re.handleStatLine(line); err != nil {
+		t.Fatalf(handleStatLineFailMsg, err)
+	}
+	key := (&model.Point{Name: "stat_value", Labels: []model.Label{
+		{Name: "name", Value: "imfoo"},
+		{Name: "origin", Value: "imfoo"},
+		{Name: "field", Value: "opened"},
+	}}).Key()
+	p, err := re.store.Get(key)
+	if err != nil {
+		t.Fatalf("expected generic point %s: %v", key, err)
+	}
+	th.AssertEqFloat(t, "opened", 3, p.Value)
+	// the numeric field and the object info
+	if want, got := 2, len(re.store.Keys()); want != got {
 		t.Errorf(th.ExpectedActualIntFmt, want, got)
 	}
 }
@@ -363,7 +666,7 @@ func TestDescribeAndCollect(t *testing.T) {
 
 	// add a point to the store
 	p := &model.Point{Name: "my_metric", Type: model.Gauge, Value: 5}
-	if err := re.Set(p); err != nil {
+	if err := re.store.Set(p); err != nil {
 		t.Fatalf("Set failed: %v", err)
 	}
 
@@ -398,7 +701,7 @@ func TestHandleAdditionalStatTypes(t *testing.T) {
 		if err := re.handleStatLine([]byte(c.line)); err != nil {
 			t.Fatalf("handleStatLine failed for case %d: %v", i, err)
 		}
-		if len(re.Keys()) == 0 {
+		if len(re.store.Keys()) == 0 {
 			t.Fatalf("case %d: expected at least one point for line %s", i, c.line)
 		}
 	}
@@ -467,7 +770,7 @@ func TestRunLoopCountsErrorsAndHandlesScannerErr(t *testing.T) {
 	}
 
 	// expect stats_line_errors to be present and value >=1
-	p, err := re.Get("stats_line_errors")
+	p, err := re.store.Get("stats_line_errors")
 	if err != nil {
 		t.Fatalf("expected stats_line_errors point: %v", err)
 	}
@@ -506,33 +809,92 @@ func TestHandleStatLineDecodeError(t *testing.T) {
 	}
 }
 
-func TestDescribeErrorBranch(t *testing.T) {
+func TestDescribeDeletedBeforeSnapshot(t *testing.T) {
 	re := New()
 	p := &model.Point{Name: "x", Type: model.Gauge, Value: 1}
-	if err := re.Set(p); err != nil {
+	if err := re.store.Set(p); err != nil {
 		t.Fatalf(setFailedFmt, err)
 	}
-	// Arrange hook to delete the point before Get to trigger error branch
-	orig := describeBeforeGetHook
-	defer func() { describeBeforeGetHook = orig }()
-	describeBeforeGetHook = func() { re.Delete(p.Key()) }
+	orig := describeBeforeSnapshotHook
+	defer func() { describeBeforeSnapshotHook = orig }()
+	describeBeforeSnapshotHook = func() { re.store.Delete(p.Key()) }
 
 	ch := make(chan *prometheus.Desc, 10)
 	re.Describe(ch)
+	close(ch)
 	// We should at least have the 'scrapes' descriptor; and not panic
 	if len(ch) == 0 {
 		t.Fatalf("expected at least one descriptor")
 	}
+	for d := range ch {
+		if strings.Contains(d.String(), `"rsyslog_x"`) {
+			t.Errorf("expected no descriptor of the deleted point, got %s", d)
+		}
+	}
+}
+
+func TestDescribeOncePerFamily(t *testing.T) {
+	re := New()
+	for _, q := range []string{"main Q", "action 0 queue"} {
+		p := &model.Point{Name: "queue_size", Type: model.Gauge, Value: 1, Labels: []model.Label{{Name: "queue", Value: q}}}
+		if err := re.store.Set(p); err != nil {
+			t.Fatalf(setFailedFmt, err)
+		}
+	}
+	ch := make(chan *prometheus.Desc, 20)
+	re.Describe(ch)
+	close(ch)
+	count := 0
+	for d := range ch {
+		if strings.Contains(d.String(), `"rsyslog_queue_size"`) {
+			count++
+		}
+	}
+	th.AssertEqInt(t, "queue_size descriptors", 1, int64(count))
+}
+
+func TestCollectReusesMetricsUntilChange(t *testing.T) {
+	re := New()
+	p := &model.Point{Name: "a", Type: model.Gauge, Value: 1}
+	if err := re.store.Set(p); err != nil {
+		t.Fatalf(setFailedFmt, err)
+	}
+	collect := func() prometheus.Metric {
+		ch := make(chan prometheus.Metric, 10)
+		re.Collect(ch)
+		close(ch)
+		for m := range ch {
+			if strings.Contains(m.Desc().String(), `"rsyslog_a"`) {
+				return m
+			}
+		}
+		t.Fatalf("expected metric rsyslog_a")
+		return nil
+	}
+	first := collect()
+	if second := collect(); second != first {
+		t.Errorf("expected the rendered metric to be reused")
+	}
+	if err := re.store.Set(&model.Point{Name: "a", Type: model.Gauge, Value: 2}); err != nil {
+		t.Fatalf(setFailedFmt, err)
+	}
+	third := collect()
+	if third == first {
+		t.Fatalf("expected a new metric after the store changed")
+	}
+	if third.Desc() != first.Desc() {
+		t.Errorf("expected the descriptor of the family to be reused")
+	}
 }
 
 func TestCollectCoversLabelAndNoLabel(t *testing.T) {
 	re := New()
 	// no-label point
-	if err := re.Set(&model.Point{Name: "a", Type: model.Gauge, Value: 1}); err != nil {
+	if err := re.store.Set(&model.Point{Name: "a", Type: model.Gauge, Value: 1}); err != nil {
 		t.Fatalf(setFailedFmt, err)
 	}
 	// with label
-	if err := re.Set(&model.Point{Name: "b", Type: model.Counter, Value: 2, LabelName: "x", LabelValue: "y"}); err != nil {
+	if err := re.store.Set(&model.Point{Name: "b", Type: model.Counter, Value: 2, Labels: []model.Label{{Name: "x", Value: "y"}}}); err != nil {
 		t.Fatalf(setFailedFmt, err)
 	}
 	ch := make(chan prometheus.Metric, 10)
@@ -542,23 +904,104 @@ func TestCollectCoversLabelAndNoLabel(t *testing.T) {
 	}
 }
 
-func TestCollectErrorBranch(t *testing.T) {
-	t.Helper()
+func TestCollectDeletedBeforeSnapshot(t *testing.T) {
 	re := New()
 	p := &model.Point{Name: "gone", Type: model.Gauge, Value: 1}
-	if err := re.Set(p); err != nil {
+	if err := re.store.Set(p); err != nil {
 		t.Fatalf(setFailedFmt, err)
 	}
-	orig := collectBeforeGetHook
-	defer func() { collectBeforeGetHook = orig }()
-	collectBeforeGetHook = func() { re.Delete(p.Key()) }
+	orig := collectBeforeSnapshotHook
+	defer func() { collectBeforeSnapshotHook = orig }()
+	collectBeforeSnapshotHook = func() { re.store.Delete(p.Key()) }
 	ch := make(chan prometheus.Metric, 10)
 	re.Collect(ch)
-	// if we reached here without panic, the error branch was exercised via continue
+	if len(ch) != 1 {
+		t.Fatalf("expected the deleted point not to be collected, got %d metrics", len(ch))
+	}
+	if m := <-ch; m.Desc() != skippedSeriesDesc {
+		t.Fatalf("expected only the skipped series count, got %s", m.Desc())
+	}
+}
+
+func TestCollectSkipsInvalidSeries(t *testing.T) {
+	var logs bytes.Buffer
+	re := New(WithLogger(log.New(&logs, "", 0)))
+	points := []*model.Point{
+		// collide once sanitized
+		{Name: "dynstat_a-b", Value: 1, Labels: []model.Label{{Name: "counter", Value: "x"}}},
+		{Name: "dynstat_a_b", Value: 2, Labels: []model.Label{{Name: "counter", Value: "x"}}},
+		// same name, different labels
+		{Name: "dynstat_a_b", Value: 3},
+		// invalid label name
+		{Name: "custom", Value: 4, Labels: []model.Label{{Name: "__reserved", Value: "x"}}},
+		// invalid UTF-8 is exported sanitized
+		{Name: "action_processed", Value: 5, Labels: []model.Label{{Name: "action", Value: "bad\xffname"}}},
+	}
+	for _, p := range points {
+		if err := re.store.Set(p); err != nil {
+			t.Fatalf(setFailedFmt, err)
+		}
+	}
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(re)
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("expected the scrape to succeed, got %v", err)
+	}
+	values := make(map[string]float64)
+	for _, f := range families {
+		for _, m := range f.GetMetric() {
+			values[f.GetName()] += m.GetGauge().GetValue() + m.GetUntyped().GetValue() + m.GetCounter().GetValue()
+		}
+	}
+	th.AssertEqFloat(t, "skipped", 3, values["rsyslog_skipped_series"])
+	th.AssertEqFloat(t, "dynstat_a_b", 1, values["rsyslog_dynstat_a_b"])
+	th.AssertEqFloat(t, "action_processed", 5, values["rsyslog_action_processed"])
+	if n := strings.Count(logs.String(), "skipping series"); n != 3 {
+		t.Errorf("expected 3 series logged as skipped, got %d: %s", n, logs.String())
+	}
+
+	// skipped series are logged once
+	if err := re.store.Set(&model.Point{Name: "other", Value: 1}); err != nil {
+		t.Fatalf(setFailedFmt, err)
+	}
+	if _, err := reg.Gather(); err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+	if n := strings.Count(logs.String(), "skipping series"); n != 3 {
+		t.Errorf("expected no further logs, got %d", n)
+	}
+}
+
+func TestCollectInvalidHostname(t *testing.T) {
+	re := New(WithHostLabel(true))
+	line := stamp(0) + " ho\xffst rsyslogd-pstats: {\"name\":\"main Q\",\"origin\":\"core.queue\",\"enqueued\":1}"
+	if err := re.handleStatLine([]byte(line)); err != nil {
+		t.Fatalf(handleStatLineFailMsg, err)
+	}
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(re)
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("expected the scrape to succeed, got %v", err)
+	}
+	hosts := make(map[string]int)
+	for _, f := range families {
+		for _, m := range f.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == model.HostLabelName {
+					hosts[l.GetValue()]++
+				}
+			}
+		}
+	}
+	if len(hosts) != 1 || hosts["ho\uFFFDst"] == 0 {
+		t.Errorf("expected every series labelled by the sanitized host, got %v", hosts)
+	}
 }
 
 const (
-	statsLineErrMsg = "expected stats_line_errors >= 1, got %d"
+	statsLineErrMsg = "expected stats_line_errors >= 1, got %v"
 	setFailedFmt    = "Set failed: %v"
 )
 
@@ -583,7 +1026,7 @@ func TestRunLoopErrorIncrementsCounterSilent(t *testing.T) {
 	}
 
 	// verify the stats_line_errors counter exists and was incremented
-	p, err := re.Get("stats_line_errors")
+	p, err := re.store.Get("stats_line_errors")
 	if err != nil {
 		t.Fatalf("expected stats_line_errors present: %v", err)
 	}
@@ -606,7 +1049,7 @@ func TestRunLoopErrorLogsWhenNotSilent(t *testing.T) {
 		t.Fatalf("unexpected error from runLoop: %v", err)
 	}
 
-	p, err := re.Get("stats_line_errors")
+	p, err := re.store.Get("stats_line_errors")
 	if err != nil {
 		t.Fatalf("expected stats_line_errors present: %v", err)
 	}
@@ -712,3 +1155,85 @@ func TestDecoderErrorBranches(t *testing.T) {
 		})
 	}
 }
+
+// sliceSource is an input.Source replaying fixed lines, optionally ending
+// with an error.
+type sliceSource struct {
+	lines []string
+	err   error
+}
+
+func (s *sliceSource) Run(ctx context.Context, lines chan<- []byte) error {
+	for _, l := range s.lines {
+		select {
+		case lines <- []byte(l):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return s.err
+}
+
+func TestRunWithSource(t *testing.T) {
+	re := New(WithSource(&sliceSource{lines: []string{string(resourceLineJSON("src", 7))}}))
+	if err := re.Run(context.Background(), true); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	p, err := findPoint(re, "resource_utime", "resource", "src")
+	if err != nil {
+		t.Fatalf("expected point from source: %v", err)
+	}
+	if p.Value != 7 {
+		t.Fatalf("unexpected value: %v", p.Value)
+	}
+}
+
+func TestRunWithSourceError(t *testing.T) {
+	boom := fmt.Errorf("test error: source failed")
+	re := New(WithSource(&sliceSource{err: boom}))
+	if err := re.Run(context.Background(), true); err != boom {
+		t.Fatalf("expected source error, got %v", err)
+	}
+}
+
+func BenchmarkHandleStatLine(b *testing.B) {
+	lines := [][]byte{
+		[]byte(`2024-01-01T00:00:00Z host rsyslogd-pstats: {"name":"main Q","origin":"core.queue","size":10,"enqueued":4032,"full":0,"discarded.full":0,"discarded.nf":0,"maxqsize":26}`),
+		[]byte(`2024-01-01T00:00:00Z host rsyslogd-pstats: {"name":"test_action","origin":"core.action","processed":100000,"failed":2,"suspended":1,"suspended.duration":1000,"resumed":1}`),
+		[]byte(`2024-01-01T00:00:00Z host rsyslogd-pstats: {"name":"global","origin":"dynstats","values":{"msg_per_host.ops_overflow":1,"msg_per_host.new_metric_add":3,"msg_per_host.no_metric":0}}`),
+	}
+	re := New()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, line := range lines {
+			if err := re.handleStatLine(line); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkCollect(b *testing.B) {
+	re := New()
+	for i := 0; i < 10000; i++ {
+		p := &model.Point{
+			Name:        "dynstat_msg_per_host",
+			Type:        model.Counter,
+			Value:       float64(i),
+			Description: "dynamic statistics bucket msg_per_host",
+			Labels:      []model.Label{{Name: "counter", Value: fmt.Sprintf("host%d.ops_overflow", i)}},
+		}
+		if err := re.store.Set(p); err != nil {
+			b.Fatal(err)
+		}
+	}
+	ch := make(chan prometheus.Metric, 11000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		re.Collect(ch)
+		for len(ch) > 0 {
+			<-ch
+		}
+	}
+}
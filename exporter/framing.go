@@ -0,0 +1,263 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/prometheus-community/rsyslog_exporter/internal/input"
+)
+
+// Framing modes accepted by NewFramer.
+const (
+	// FramingColumns expects "TIMESTAMP HOSTNAME TAG PAYLOAD", the layout of
+	// rsyslog's default omprog template.
+	FramingColumns = "columns"
+	// FramingAuto locates the impstats payload within the line and takes
+	// header fields from whatever precedes it.
+	FramingAuto = "auto"
+	// FramingRaw treats the whole line as payload, e.g. for a bare %msg%
+	// template.
+	FramingRaw = "raw"
+	// FramingRegex strips a user-supplied prefix regular expression whose
+	// named captures provide the header fields.
+	FramingRegex = "regex"
+)
+
+// Named captures understood in FramingRegex patterns.
+const (
+	captureTimestamp = "timestamp"
+	captureHostname  = "hostname"
+	captureTag       = "tag"
+)
+
+var ErrNoPayload = errors.New("no impstats payload found")
+
+// Line is a stats line split into its header fields and impstats payload.
+// Header fields missing from the line are empty.
+type Line struct {
+	Timestamp string
+	Hostname  string
+	Tag       string
+	Payload   []byte
+}
+
+// Framer splits raw stats lines into a Line.
+type Framer interface {
+	Frame(raw []byte) (Line, error)
+}
+
+// clockFramer is implemented by framers that need the current time, e.g. to
+// complete the year of RFC 3164 timestamps. The exporter hands them its
+// clock.
+type clockFramer interface {
+	withClock(clock func() time.Time) Framer
+}
+
+// NewFramer returns the Framer for mode. pattern is only used, and then
+// required, by FramingRegex.
+func NewFramer(mode, pattern string) (Framer, error) {
+	switch mode {
+	case FramingColumns:
+		return columnsFramer{}, nil
+	case FramingAuto:
+		return autoFramer{}, nil
+	case FramingRaw:
+		return rawFramer{}, nil
+	case FramingRegex:
+		return newRegexFramer(pattern)
+	}
+	return nil, fmt.Errorf("unknown framing %q", mode)
+}
+
+// columnsFramer implements FramingColumns.
+type columnsFramer struct{}
+
+func (columnsFramer) Frame(raw []byte) (Line, error) {
+	s := bytes.SplitN(raw, []byte(" "), 4)
+	if len(s) != 4 {
+		return Line{}, fmt.Errorf("failed to split log line, expected 4 columns, got: %v", len(s))
+	}
+	return Line{
+		Timestamp: string(s[0]),
+		Hostname:  string(s[1]),
+		Tag:       string(bytes.TrimSuffix(s[2], []byte(":"))),
+		Payload:   s[3],
+	}, nil
+}
+
+// rawFramer implements FramingRaw.
+type rawFramer struct{}
+
+func (rawFramer) Frame(raw []byte) (Line, error) {
+	payload := bytes.TrimSpace(raw)
+	if len(payload) == 0 {
+		return Line{}, ErrNoPayload
+	}
+	return Line{Payload: payload}, nil
+}
+
+// autoFramer implements FramingAuto. Lines starting with a syslog priority
+// are parsed as RFC 5424 or RFC 3164 messages. Otherwise the payload starts
+// at the first "{" or "@cee:". Lines without either, such as legacy format
+// stats, are split into columns.
+type autoFramer struct {
+	// clock returns the current time; the package clock if nil.
+	clock func() time.Time
+}
+
+func (f autoFramer) withClock(clock func() time.Time) Framer {
+	f.clock = clock
+	return f
+}
+
+func (f autoFramer) now() time.Time {
+	if f.clock == nil {
+		return now()
+	}
+	return f.clock()
+}
+
+func (f autoFramer) Frame(raw []byte) (Line, error) {
+	if len(raw) > 0 && raw[0] == '<' {
+		if m, err := input.ParseMessage(raw, f.now()); err == nil {
+			return messageLine(m)
+		}
+	}
+	start := payloadStart(raw)
+	if start < 0 {
+		return columnsFramer{}.Frame(raw)
+	}
+	l := headerFields(raw[:start], f.now())
+	l.Payload = raw[start:]
+	return l, nil
+}
+
+// messageLine returns the Line of the syslog message m. Its content is the
+// payload, from the first "{" or "@cee:" on if there is one.
+func messageLine(m *input.Message) (Line, error) {
+	payload := m.Content
+	if start := payloadStart(payload); start > 0 {
+		payload = payload[start:]
+	}
+	if len(bytes.TrimSpace(payload)) == 0 {
+		return Line{}, ErrNoPayload
+	}
+	l := Line{Hostname: m.Hostname, Tag: m.Tag, Payload: payload}
+	if !m.Timestamp.IsZero() {
+		l.Timestamp = m.Timestamp.Format(time.RFC3339Nano)
+	}
+	return l, nil
+}
+
+// payloadStart returns the index of the first "{" or "@cee:" in raw, or -1.
+func payloadStart(raw []byte) int {
+	brace := bytes.IndexByte(raw, '{')
+	cee := bytes.Index(raw, []byte("@cee:"))
+	switch {
+	case brace < 0:
+		return cee
+	case cee < 0:
+		return brace
+	}
+	return min(brace, cee)
+}
+
+// headerFields extracts timestamp, hostname and tag from the text preceding
+// a payload, e.g. the omprog columns or an RFC 3164 header without
+// priority. The timestamp is the first RFC 3339 or RFC 3164 timestamp, the
+// latter converted to RFC 3339 in the year closest to at; hostname and tag
+// follow it.
+func headerFields(header []byte, at time.Time) Line {
+	tokens := bytes.Fields(header)
+	for i, tok := range tokens {
+		var l Line
+		rest := tokens[i+1:]
+		if _, err := time.Parse(time.RFC3339Nano, string(tok)); err == nil {
+			l.Timestamp = string(tok)
+		} else if ts, ok := stampAt(tokens[i:], at); ok {
+			l.Timestamp = ts.Format(time.RFC3339Nano)
+			rest = tokens[i+3:]
+		} else {
+			continue
+		}
+		if len(rest) > 0 {
+			l.Hostname = string(rest[0])
+		}
+		if len(rest) > 1 {
+			l.Tag = string(bytes.TrimSuffix(rest[1], []byte(":")))
+		}
+		return l
+	}
+	return Line{}
+}
+
+// stampAt parses the RFC 3164 timestamp "Mmm dd hh:mm:ss" spanning the
+// first three tokens, if any, as seen at the time at.
+func stampAt(tokens [][]byte, at time.Time) (time.Time, bool) {
+	if len(tokens) < 3 || len(tokens[0]) != 3 {
+		return time.Time{}, false
+	}
+	ts, err := input.ParseStamp(string(bytes.Join(tokens[:3], []byte(" "))), at)
+	return ts, err == nil
+}
+
+// regexFramer implements FramingRegex.
+type regexFramer struct {
+	re                       *regexp.Regexp
+	timestamp, hostname, tag int
+}
+
+func newRegexFramer(pattern string) (*regexFramer, error) {
+	if pattern == "" {
+		return nil, errors.New("framing regex requires a pattern")
+	}
+	// anchor the prefix at the start of the line
+	re, err := regexp.Compile(`^(?:` + pattern + `)`)
+	if err != nil {
+		return nil, fmt.Errorf("invalid framing regex: %w", err)
+	}
+	return &regexFramer{
+		re:        re,
+		timestamp: re.SubexpIndex(captureTimestamp),
+		hostname:  re.SubexpIndex(captureHostname),
+		tag:       re.SubexpIndex(captureTag),
+	}, nil
+}
+
+func (f *regexFramer) Frame(raw []byte) (Line, error) {
+	m := f.re.FindSubmatchIndex(raw)
+	if m == nil {
+		return Line{}, fmt.Errorf("line does not match framing regex %q", f.re)
+	}
+	return Line{
+		Timestamp: submatch(raw, m, f.timestamp),
+		Hostname:  submatch(raw, m, f.hostname),
+		Tag:       submatch(raw, m, f.tag),
+		Payload:   bytes.TrimLeft(raw[m[1]:], " "),
+	}, nil
+}
+
+// submatch returns capture group idx of match m in b, or "" if the group
+// does not exist or did not participate in the match.
+func submatch(b []byte, m []int, idx int) string {
+	if idx < 0 || m[2*idx] < 0 {
+		return ""
+	}
+	return string(b[m[2*idx]:m[2*idx+1]])
+}
@@ -0,0 +1,186 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"testing"
+	"time"
+
+	th "github.com/prometheus-community/rsyslog_exporter/internal/testhelpers"
+)
+
+const (
+	framingStamp   = "2017-08-30T08:10:04.786350+00:00"
+	framingHost    = "some-node.example.org"
+	framingTag     = "rsyslogd-pstats"
+	framingPayload = `{"name":"main Q","enqueued":20}`
+)
+
+func assertLine(t *testing.T, want, got Line) {
+	t.Helper()
+	th.AssertEqString(t, "timestamp", want.Timestamp, got.Timestamp)
+	th.AssertEqString(t, "hostname", want.Hostname, got.Hostname)
+	th.AssertEqString(t, "tag", want.Tag, got.Tag)
+	th.AssertEqString(t, "payload", string(want.Payload), string(got.Payload))
+}
+
+func TestNewFramerErrors(t *testing.T) {
+	if _, err := NewFramer("xml", ""); err == nil {
+		t.Errorf("expected error for unknown framing")
+	}
+	if _, err := NewFramer(FramingRegex, ""); err == nil {
+		t.Errorf("expected error for missing regex")
+	}
+	if _, err := NewFramer(FramingRegex, "("); err == nil {
+		t.Errorf("expected error for invalid regex")
+	}
+}
+
+func TestColumnsFramer(t *testing.T) {
+	f, err := NewFramer(FramingColumns, "")
+	if err != nil {
+		t.Fatalf("NewFramer failed: %v", err)
+	}
+	got, err := f.Frame([]byte(framingStamp + " " + framingHost + " " + framingTag + ": " + framingPayload))
+	if err != nil {
+		t.Fatalf("Frame failed: %v", err)
+	}
+	assertLine(t, Line{framingStamp, framingHost, framingTag, []byte(framingPayload)}, got)
+
+	if _, err := f.Frame([]byte(framingPayload)); err == nil {
+		t.Errorf("expected error for a bare payload")
+	}
+}
+
+func TestRawFramer(t *testing.T) {
+	f, err := NewFramer(FramingRaw, "")
+	if err != nil {
+		t.Fatalf("NewFramer failed: %v", err)
+	}
+	got, err := f.Frame([]byte(" " + framingPayload + " "))
+	if err != nil {
+		t.Fatalf("Frame failed: %v", err)
+	}
+	assertLine(t, Line{Payload: []byte(framingPayload)}, got)
+
+	if _, err := f.Frame([]byte("  ")); err == nil {
+		t.Errorf("expected error for an empty line")
+	}
+}
+
+func TestAutoFramer(t *testing.T) {
+	origNow := now
+	defer func() { now = origNow }()
+	now = func() time.Time { return time.Date(2024, time.October, 20, 0, 0, 0, 0, time.UTC) }
+
+	full := Line{framingStamp, framingHost, framingTag, []byte(framingPayload)}
+	// syslog timestamps are rendered in RFC 3339
+	parsed := full
+	parsed.Timestamp = "2017-08-30T08:10:04.78635Z"
+	bsd := Line{"2024-10-16T13:59:47Z", "relay-03", framingTag, []byte(framingPayload)}
+	cases := []struct {
+		name string
+		line string
+		want Line
+	}{
+		{"columns", framingStamp + " " + framingHost + " " + framingTag + ": " + framingPayload, full},
+		{"bare", framingPayload, Line{Payload: []byte(framingPayload)}},
+		{"rfc5424", "<46>1 " + framingStamp + " " + framingHost + " " + framingTag + ` - - [meta sequenceId="1"] ` + framingPayload, parsed},
+		{"rfc5424 brace in structured data", "<46>1 " + framingStamp + " " + framingHost + " " + framingTag + ` - - [x@1 a="{"] ` + framingPayload, parsed},
+		{"rfc3164", "<46>Oct 16 13:59:47 relay-03 " + framingTag + ": " + framingPayload, bsd},
+		{"rfc3164 without priority", "Oct 16 13:59:47 relay-03 " + framingTag + ": " + framingPayload, bsd},
+		{"rfc3164 single digit day", "Oct  6 13:59:47 relay-03 " + framingTag + ": " + framingPayload,
+			Line{"2024-10-06T13:59:47Z", "relay-03", framingTag, []byte(framingPayload)}},
+		{"extra fields", "relay " + framingStamp + " " + framingHost + " " + framingTag + ": " + framingPayload, full},
+		{"cee", framingStamp + " " + framingHost + " " + framingTag + ": @cee: " + framingPayload,
+			Line{framingStamp, framingHost, framingTag, []byte("@cee: " + framingPayload)}},
+		{"legacy", framingStamp + " " + framingHost + " " + framingTag + ": main Q: size=1",
+			Line{framingStamp, framingHost, framingTag, []byte("main Q: size=1")}},
+	}
+	f, err := NewFramer(FramingAuto, "")
+	if err != nil {
+		t.Fatalf("NewFramer failed: %v", err)
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := f.Frame([]byte(c.line))
+			if err != nil {
+				t.Fatalf("Frame failed: %v", err)
+			}
+			assertLine(t, c.want, got)
+		})
+	}
+}
+
+func TestAutoFramerExporterClock(t *testing.T) {
+	f, err := NewFramer(FramingAuto, "")
+	if err != nil {
+		t.Fatalf("NewFramer failed: %v", err)
+	}
+	clock := func() time.Time { return time.Date(2019, time.January, 2, 0, 0, 0, 0, time.UTC) }
+	re := New(WithFraming(f), WithClock(clock))
+	// the year of RFC 3164 timestamps comes from the exporter's clock
+	for _, line := range []string{
+		"<46>Dec 31 23:59:59 relay-03 " + framingTag + ": " + framingPayload,
+		"Dec 31 23:59:59 relay-03 " + framingTag + ": " + framingPayload,
+	} {
+		got, err := re.framer.Frame([]byte(line))
+		if err != nil {
+			t.Fatalf("Frame failed: %v", err)
+		}
+		th.AssertEqString(t, "timestamp", "2018-12-31T23:59:59Z", got.Timestamp)
+	}
+}
+
+func TestRegexFramer(t *testing.T) {
+	f, err := NewFramer(FramingRegex, `(?P<hostname>\S+) (?P<tag>[^:]+): \[(?P<timestamp>[^]]+)\]`)
+	if err != nil {
+		t.Fatalf("NewFramer failed: %v", err)
+	}
+	got, err := f.Frame([]byte(framingHost + " " + framingTag + ": [" + framingStamp + "] " + framingPayload))
+	if err != nil {
+		t.Fatalf("Frame failed: %v", err)
+	}
+	assertLine(t, Line{framingStamp, framingHost, framingTag, []byte(framingPayload)}, got)
+
+	// the prefix is anchored at the start of the line
+	if _, err := f.Frame([]byte("x " + framingPayload)); err == nil {
+		t.Errorf("expected error for a non-matching line")
+	}
+
+	// captures are optional
+	f, err = NewFramer(FramingRegex, `stats:`)
+	if err != nil {
+		t.Fatalf("NewFramer failed: %v", err)
+	}
+	got, err = f.Frame([]byte("stats: " + framingPayload))
+	if err != nil {
+		t.Fatalf("Frame failed: %v", err)
+	}
+	assertLine(t, Line{Payload: []byte(framingPayload)}, got)
+}
+
+func TestHandleStatLineWithFraming(t *testing.T) {
+	f, err := NewFramer(FramingRaw, "")
+	if err != nil {
+		t.Fatalf("NewFramer failed: %v", err)
+	}
+	re := New(WithFraming(f))
+	if err := re.handleStatLine([]byte(framingPayload)); err != nil {
+		t.Fatalf(handleStatLineFailMsg, err)
+	}
+	if _, err := findPoint(re, "queue_enqueued", "queue", "main Q"); err != nil {
+		t.Fatalf("expected point from raw line: %v", err)
+	}
+}
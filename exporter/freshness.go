@@ -0,0 +1,220 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus-community/rsyslog_exporter/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// now is the default clock of exporters; tests replace it.
+var now = time.Now
+
+// batchGap is the minimum distance between the timestamps of two lines for
+// them to belong to different impstats batches. impstats emits all objects
+// of an interval at once and its interval is at least one second.
+const batchGap = 500 * time.Millisecond
+
+// maxGaps is the number of recent batch distances the impstats interval is
+// detected from.
+const maxGaps = 5
+
+// sourceTimes is what is known about the stats timing of one host.
+type sourceTimes struct {
+	received   time.Time            // arrival of the last stats line
+	lastStamp  map[string]time.Time // newest line timestamp per type
+	batchStart time.Time            // timestamp of the current batch
+	gaps       []time.Duration      // distances of the recent batches
+	interval   time.Duration        // median of gaps
+}
+
+// freshness tracks when stats were last emitted and received, and detects
+// the impstats interval from the distance of consecutive batches. With
+// timestamps of one second resolution, an emission crossing a second
+// boundary looks like two batches a second apart; the median of the recent
+// distances keeps such a split from replacing the interval. Hosts are keyed
+// by their host label value, "" when the host label is disabled.
+type freshness struct {
+	lock    sync.Mutex
+	sources map[string]*sourceTimes
+}
+
+func newFreshness() *freshness {
+	return &freshness{sources: make(map[string]*sourceTimes)}
+}
+
+// lineTime returns the time a stats line was emitted: its RFC 3339 timestamp
+// or, without one, the time it was received.
+func lineTime(stamp string, received time.Time) time.Time {
+	ts, err := time.Parse(time.RFC3339Nano, stamp)
+	if err != nil {
+		return received
+	}
+	return ts
+}
+
+// observe records a stats line of type typ from host, emitted at ts and
+// received at received.
+func (f *freshness) observe(host string, typ string, ts, received time.Time) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	s, ok := f.sources[host]
+	if !ok {
+		s = &sourceTimes{lastStamp: make(map[string]time.Time)}
+		f.sources[host] = s
+	}
+	s.received = received
+	if ts.After(s.lastStamp[typ]) {
+		s.lastStamp[typ] = ts
+	}
+	switch {
+	case s.batchStart.IsZero() || ts.Before(s.batchStart.Add(-batchGap)):
+		// first batch, or the clock of the host went backwards
+		s.batchStart = ts
+	case ts.Sub(s.batchStart) >= batchGap:
+		s.addGap(ts.Sub(s.batchStart))
+		s.batchStart = ts
+	}
+}
+
+// addGap records the distance gap of two batches and updates the interval.
+func (s *sourceTimes) addGap(gap time.Duration) {
+	if len(s.gaps) == maxGaps {
+		s.gaps = s.gaps[1:]
+	}
+	s.gaps = append(s.gaps, gap)
+	sorted := slices.Clone(s.gaps)
+	slices.Sort(sorted)
+	s.interval = sorted[len(sorted)/2]
+}
+
+// interval returns the impstats interval detected for host, or 0 if it is
+// not known yet.
+func (f *freshness) interval(host string) time.Duration {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if s, ok := f.sources[host]; ok {
+		return s.interval
+	}
+	return 0
+}
+
+// minStableGaps is the number of batch distances an interval must be
+// detected from before series are expired by it.
+const minStableGaps = 3
+
+// stableInterval returns the impstats interval of host once it was detected
+// from at least minStableGaps batches, or 0.
+func (f *freshness) stableInterval(host string) time.Duration {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if s, ok := f.sources[host]; ok && len(s.gaps) >= minStableGaps {
+		return s.interval
+	}
+	return 0
+}
+
+// shortestStableInterval returns the shortest stable impstats interval of
+// all hosts, or 0 if none is known yet.
+func (f *freshness) shortestStableInterval() time.Duration {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	var shortest time.Duration
+	for _, s := range f.sources {
+		if len(s.gaps) >= minStableGaps && (shortest == 0 || s.interval < shortest) {
+			shortest = s.interval
+		}
+	}
+	return shortest
+}
+
+// hostLabelNames returns the host label name for series of host, if any.
+func hostLabelNames(host string) []string {
+	if host == "" {
+		return nil
+	}
+	return []string{model.HostLabelName}
+}
+
+// hostLabelValues returns the host label value for series of host, if any.
+func hostLabelValues(host string) []string {
+	if host == "" {
+		return nil
+	}
+	return []string{host}
+}
+
+// freshnessDescs returns the descriptors of the freshness metrics for host.
+func freshnessDescs(host string) (lastTimestamp, interval, age *prometheus.Desc) {
+	labels := hostLabelNames(host)
+	lastTimestamp = prometheus.NewDesc(
+		prometheus.BuildFQName("", "rsyslog", "stats_last_timestamp_seconds"),
+		"Timestamp of the newest impstats line per object type, in seconds since the epoch",
+		append([]string{"type"}, labels...), nil,
+	)
+	interval = prometheus.NewDesc(
+		prometheus.BuildFQName("", "rsyslog", "impstats_interval_seconds"),
+		"impstats interval detected from the distance of consecutive stats batches",
+		labels, nil,
+	)
+	age = prometheus.NewDesc(
+		prometheus.BuildFQName("", "rsyslog", "stats_age_seconds"),
+		"Seconds since the last impstats line was received",
+		labels, nil,
+	)
+	return lastTimestamp, interval, age
+}
+
+func (f *freshness) describe(ch chan<- *prometheus.Desc) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for host := range f.sources {
+		lastTimestamp, interval, age := freshnessDescs(host)
+		ch <- lastTimestamp
+		ch <- interval
+		ch <- age
+	}
+}
+
+// collect sends the freshness metrics of all hosts to ch and returns the
+// number of metrics that could not be created.
+func (f *freshness) collect(ch chan<- prometheus.Metric, at time.Time) int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	skipped := 0
+	send := func(desc *prometheus.Desc, v float64, labels ...string) {
+		m, err := prometheus.NewConstMetric(desc, prometheus.GaugeValue, v, labels...)
+		if err != nil {
+			skipped++
+			return
+		}
+		ch <- m
+	}
+	for host, s := range f.sources {
+		lastTimestamp, interval, age := freshnessDescs(host)
+		hostValue := hostLabelValues(host)
+		for typ, ts := range s.lastStamp {
+			send(lastTimestamp, float64(ts.UnixNano())/1e9, append([]string{typ}, hostValue...)...)
+		}
+		if s.interval > 0 {
+			send(interval, s.interval.Seconds(), hostValue...)
+		}
+		send(age, at.Sub(s.received).Seconds(), hostValue...)
+	}
+	return skipped
+}
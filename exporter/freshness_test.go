@@ -0,0 +1,135 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var freshnessStart = time.Date(2025, time.March, 4, 12, 0, 0, 0, time.UTC)
+
+// stamp formats freshnessStart plus d like the omprog template does.
+func stamp(d time.Duration) string {
+	return freshnessStart.Add(d).Format(time.RFC3339Nano)
+}
+
+func TestFreshnessInterval(t *testing.T) {
+	f := newFreshness()
+	received := freshnessStart
+	// two lines per batch, a few milliseconds apart
+	for _, d := range []time.Duration{0, 3 * time.Millisecond, 30 * time.Second, 30*time.Second + 2*time.Millisecond} {
+		f.observe("", "queue", freshnessStart.Add(d), received)
+	}
+	s := f.sources[""]
+	if want, got := 30*time.Second, s.interval; want != got {
+		t.Fatalf("want interval %v, got %v", want, got)
+	}
+	if want, got := freshnessStart.Add(30*time.Second+2*time.Millisecond), s.lastStamp["queue"]; !want.Equal(got) {
+		t.Fatalf("want last timestamp %v, got %v", want, got)
+	}
+
+	// the interval changes once most recent batches agree
+	for _, d := range []time.Duration{40 * time.Second, 50 * time.Second, 60 * time.Second} {
+		f.observe("", "queue", freshnessStart.Add(d), received)
+	}
+	if want, got := 10*time.Second, s.interval; want != got {
+		t.Fatalf("want interval %v, got %v", want, got)
+	}
+
+	// a clock going backwards starts over without losing the interval
+	f.observe("", "queue", freshnessStart, received)
+	if want, got := freshnessStart, s.batchStart; !want.Equal(got) {
+		t.Fatalf("want batch start %v, got %v", want, got)
+	}
+	if want, got := 10*time.Second, s.interval; want != got {
+		t.Fatalf("want interval %v, got %v", want, got)
+	}
+}
+
+func TestFreshnessIntervalSplitEmission(t *testing.T) {
+	f := newFreshness()
+	received := freshnessStart
+	// one second resolution: the emission at 30s crosses a second boundary
+	for _, d := range []time.Duration{0, 0, 30 * time.Second, 31 * time.Second, 60 * time.Second, 60 * time.Second, 90 * time.Second} {
+		f.observe("", "queue", freshnessStart.Add(d), received)
+		if got := f.interval(""); got != 0 && got < 29*time.Second {
+			t.Fatalf("interval dropped to %v after the line at %v", got, d)
+		}
+	}
+	if want, got := 30*time.Second, f.interval(""); want != got {
+		t.Fatalf("want interval %v, got %v", want, got)
+	}
+}
+
+func TestLineTime(t *testing.T) {
+	received := freshnessStart.Add(time.Minute)
+	if got := lineTime(stamp(0), received); !got.Equal(freshnessStart) {
+		t.Fatalf("want %v, got %v", freshnessStart, got)
+	}
+	// lines without timestamp are taken as emitted when received
+	if got := lineTime("-", received); !got.Equal(received) {
+		t.Fatalf("want %v, got %v", received, got)
+	}
+}
+
+func TestFreshnessCollect(t *testing.T) {
+	f := newFreshness()
+	f.observe("relay-03", "queue", freshnessStart, freshnessStart)
+	f.observe("relay-03", "action", freshnessStart, freshnessStart)
+
+	// no interval yet: two last timestamps and the age
+	ch := make(chan prometheus.Metric, 8)
+	f.collect(ch, freshnessStart.Add(time.Minute))
+	if want, got := 3, len(ch); want != got {
+		t.Fatalf("want %d metrics, got %d", want, got)
+	}
+
+	f.observe("relay-03", "queue", freshnessStart.Add(time.Minute), freshnessStart.Add(time.Minute))
+	ch = make(chan prometheus.Metric, 8)
+	f.collect(ch, freshnessStart.Add(time.Minute))
+	if want, got := 4, len(ch); want != got {
+		t.Fatalf("want %d metrics, got %d", want, got)
+	}
+
+	descCh := make(chan *prometheus.Desc, 8)
+	f.describe(descCh)
+	if want, got := 3, len(descCh); want != got {
+		t.Fatalf("want %d descriptors, got %d", want, got)
+	}
+	if d := (<-descCh).String(); !strings.Contains(d, "variableLabels: {type,host}") {
+		t.Fatalf("expected type and host labels: %s", d)
+	}
+}
+
+func TestHandleLineObservesFreshness(t *testing.T) {
+	origNow := now
+	defer func() { now = origNow }()
+	now = func() time.Time { return freshnessStart }
+
+	re := New()
+	if err := re.handleStatLine([]byte(stamp(0) + ` host rsyslogd-pstats: {"name":"main Q","enqueued":1}`)); err != nil {
+		t.Fatalf(handleStatLineFailMsg, err)
+	}
+	s, ok := re.freshness.sources[""]
+	if !ok {
+		t.Fatalf("expected freshness to be tracked")
+	}
+	if !s.received.Equal(freshnessStart) {
+		t.Fatalf("want received %v, got %v", freshnessStart, s.received)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"sort"
+
+	"github.com/prometheus-community/rsyslog_exporter/decoder"
+	"github.com/prometheus-community/rsyslog_exporter/internal/model"
+)
+
+const (
+	// objectInfoName is the name of the info points of impstats objects.
+	objectInfoName = "object_info"
+	// objectTypeLabel labels object info and counts by the object type.
+	objectTypeLabel = "type"
+)
+
+// objectInfo returns the info point of the impstats object obj, which was
+// decoded by dec. Stored along with the points of the object, it expires
+// with them, so that its disappearance can be alerted on.
+func objectInfo(obj decoder.Object, dec decoder.Decoder) *model.Point {
+	return &model.Point{
+		Name:        objectInfoName,
+		Type:        model.Info,
+		Description: "impstats objects by type, name and origin",
+		Labels: []model.Label{
+			{Name: objectTypeLabel, Value: dec.Name()},
+			{Name: "name", Value: obj.Name},
+			{Name: model.OriginLabelName, Value: obj.Origin},
+		},
+	}
+}
+
+// objectCounts returns the number of objects per type and host among the
+// info points of points.
+func objectCounts(points []*model.Point) []*model.Point {
+	type group struct{ typ, host string }
+	counts := make(map[group]int)
+	for _, p := range points {
+		if p.Name == objectInfoName && p.ObjectType != "" {
+			counts[group{p.Label(objectTypeLabel), p.Host}]++
+		}
+	}
+	objects := make([]*model.Point, 0, len(counts))
+	for g, n := range counts {
+		objects = append(objects, &model.Point{
+			Name:        "objects",
+			Type:        model.Gauge,
+			Value:       float64(n),
+			Description: "impstats objects currently known, by type",
+			Labels:      []model.Label{{Name: objectTypeLabel, Value: g.typ}},
+			Host:        g.host,
+		})
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key() < objects[j].Key() })
+	return objects
+}
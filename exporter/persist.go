@@ -0,0 +1,150 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/prometheus-community/rsyslog_exporter/internal/fileutil"
+	"github.com/prometheus-community/rsyslog_exporter/internal/model"
+)
+
+// DefaultPersistInterval is how often the store is written to its snapshot
+// file when no interval is given.
+const DefaultPersistInterval = time.Minute
+
+// snapshotVersion is the format version of snapshot files. Version 2 keys
+// series by their full label set, version 3 adds the origin label, version 4
+// the labels parsed from object names, version 5 the queue_kind label,
+// version 6 the restarts accounted for per counter and version 7 the queue
+// owner label.
+const snapshotVersion = 7
+
+// snapshot is the persisted state of an Exporter.
+type snapshot struct {
+	Version   int                      `json:"version"`
+	Saved     time.Time                `json:"saved"`
+	Points    []savedPoint             `json:"points"`
+	Counters  map[string]*counterState `json:"counters"`
+	Processes map[string]*processState `json:"processes"`
+}
+
+// savedPoint is a point together with the time it was last set.
+type savedPoint struct {
+	*model.Point
+	Updated time.Time `json:"updated"`
+}
+
+// persistence configures the snapshot file of an Exporter.
+type persistence struct {
+	path     string
+	interval time.Duration
+	maxAge   time.Duration
+}
+
+// WithPersistence keeps the store, including the counter state used for
+// restart detection and accumulation, in the snapshot file at path. It is
+// written every interval and when Run returns, and restored by Run unless
+// older than maxAge. A zero interval selects DefaultPersistInterval, a zero
+// maxAge restores snapshots of any age.
+func WithPersistence(path string, interval, maxAge time.Duration) Option {
+	return func(e *Exporter) {
+		if interval <= 0 {
+			interval = DefaultPersistInterval
+		}
+		e.persist = persistence{path: path, interval: interval, maxAge: maxAge}
+	}
+}
+
+// runPersisted runs the exporter loop between restoring and saving the
+// snapshot, saving it periodically meanwhile.
+func (re *Exporter) runPersisted(ctx context.Context, silent bool) error {
+	re.restore()
+
+	saveCtx, stop := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(re.persist.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-saveCtx.Done():
+				return
+			case <-ticker.C:
+				re.save()
+			}
+		}
+	}()
+
+	err := re.runLoop(ctx, silent)
+	stop()
+	<-done
+	re.save()
+	return err
+}
+
+// save writes the snapshot file. Errors are logged; the next save retries.
+func (re *Exporter) save() {
+	s := snapshot{Version: snapshotVersion, Saved: re.clock()}
+	for _, e := range re.store.Entries() {
+		s.Points = append(s.Points, savedPoint{Point: e.Point, Updated: e.Updated})
+	}
+	s.Counters, s.Processes = re.restarts.state()
+	b, err := json.Marshal(s)
+	if err != nil {
+		re.logger.Printf("failed to encode snapshot: %v", err)
+		return
+	}
+	if err := fileutil.WriteAtomic(re.persist.path, b); err != nil {
+		re.logger.Printf("failed to write snapshot: %v", err)
+	}
+}
+
+// restore loads the snapshot file into the store unless it is missing,
+// invalid or too old.
+func (re *Exporter) restore() {
+	b, err := os.ReadFile(re.persist.path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			re.logger.Printf("failed to read snapshot %s: %v", re.persist.path, err)
+		}
+		return
+	}
+	var s snapshot
+	if err := json.Unmarshal(b, &s); err != nil {
+		re.logger.Printf("ignoring invalid snapshot %s: %v", re.persist.path, err)
+		return
+	}
+	if s.Version != snapshotVersion {
+		re.logger.Printf("ignoring snapshot %s of unsupported version %d", re.persist.path, s.Version)
+		return
+	}
+	if age := re.clock().Sub(s.Saved); re.persist.maxAge > 0 && age > re.persist.maxAge {
+		re.logger.Printf("ignoring snapshot %s saved %v ago", re.persist.path, age.Round(time.Second))
+		return
+	}
+	for _, p := range s.Points {
+		if p.Point != nil {
+			_ = re.store.SetAt(p.Point, p.Updated)
+		}
+	}
+	re.restarts.restore(s.Counters, s.Processes)
+	re.logger.Printf("restored %d points from snapshot %s", len(s.Points), re.persist.path)
+}
@@ -0,0 +1,127 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus-community/rsyslog_exporter/internal/model"
+)
+
+// withClock sets the exporter clock to at for the duration of the test.
+func withClock(t *testing.T, at time.Time) {
+	t.Helper()
+	origNow := now
+	t.Cleanup(func() { now = origNow })
+	now = func() time.Time { return at }
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	withClock(t, freshnessStart)
+	path := filepath.Join(t.TempDir(), "store.json")
+
+	re := New(WithPersistence(path, 0, time.Hour), WithMonotonicCounters(true))
+	emission(t, re, 0, 100, 5000)
+	emission(t, re, 10*time.Second, 10, 100)
+	re.save()
+
+	restored := New(WithPersistence(path, 0, time.Hour), WithMonotonicCounters(true))
+	restored.restore()
+	if got := enqueued(t, restored, "main Q"); got != 110 {
+		t.Fatalf("want restored enqueued 110, got %d", got)
+	}
+	if got := restored.restarts.processes[""].Restarts; got != 1 {
+		t.Fatalf("want restored restart count 1, got %d", got)
+	}
+
+	// accumulation continues where the previous exporter left off
+	emission(t, restored, 20*time.Second, 20, 200)
+	if got := enqueued(t, restored, "main Q"); got != 120 {
+		t.Fatalf("want enqueued 100+20, got %d", got)
+	}
+}
+
+func TestSnapshotMaxAge(t *testing.T) {
+	withClock(t, freshnessStart)
+	path := filepath.Join(t.TempDir(), "store.json")
+	re := New(WithPersistence(path, 0, time.Hour))
+	emission(t, re, 0, 100, 5000)
+	re.save()
+
+	withClock(t, freshnessStart.Add(2*time.Hour))
+	restored := New(WithPersistence(path, 0, time.Hour))
+	restored.restore()
+	if len(restored.store.Keys()) != 0 {
+		t.Fatalf("expected a snapshot older than max age to be ignored")
+	}
+
+	// without max age snapshots of any age are restored
+	restored = New(WithPersistence(path, 0, 0))
+	restored.restore()
+	if len(restored.store.Keys()) == 0 {
+		t.Fatalf("expected snapshot to be restored without max age")
+	}
+}
+
+func TestSnapshotInvalid(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"garbage.json": "{",
+		"version.json": `{"version":99,"points":[{"Name":"x"}]}`,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		re := New(WithPersistence(path, 0, 0))
+		re.restore()
+		if len(re.store.Keys()) != 0 {
+			t.Errorf("%s: expected snapshot to be ignored", name)
+		}
+	}
+	// a missing file is fine on first start
+	New(WithPersistence(filepath.Join(dir, "missing.json"), 0, 0)).restore()
+}
+
+func TestRunPersisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+	src := &sliceSource{lines: []string{string(resourceLineJSON("src", 7)), "bogus"}}
+	re := New(WithSource(src), WithPersistence(path, time.Millisecond, 0))
+	if err := re.Run(context.Background(), true); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected snapshot to be written when Run returns: %v", err)
+	}
+
+	// the next run starts from the snapshot, including the error count
+	restarted := New(WithSource(&sliceSource{}), WithPersistence(path, time.Millisecond, 0))
+	if err := restarted.Run(context.Background(), true); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if _, err := findPoint(restarted, "resource_utime", "resource", "src"); err != nil {
+		t.Fatalf("expected point restored from snapshot: %v", err)
+	}
+	p, err := restarted.store.Get("stats_line_errors")
+	if err != nil || p.Value != 1 {
+		t.Fatalf("want restored stats_line_errors 1, got %v (err %v)", p.Value, err)
+	}
+	if p.Type != model.Counter {
+		t.Fatalf("want counter type, got %v", p.Type)
+	}
+}
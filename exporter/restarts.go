@@ -0,0 +1,294 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus-community/rsyslog_exporter/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// counterState is the last value rsyslog reported for a counter and the
+// amount added to it to keep the exported counter monotonic. For delta
+// counters Offset is the running total.
+type counterState struct {
+	Raw    float64 `json:"raw"`
+	Offset float64 `json:"offset"`
+	// Restarts is the number of restarts of the host accounted for in
+	// Offset.
+	Restarts int64 `json:"restarts"`
+}
+
+// processState is what is known about the rsyslog process of one host.
+type processState struct {
+	Restarts    int64     `json:"restarts"`
+	Start       time.Time `json:"start"`        // estimated process start
+	LastRestart time.Time `json:"last_restart"` // when the last restart was counted
+}
+
+// restartTracker detects rsyslog restarts from its resource usage going
+// backwards, which starts again from zero with every process and, unlike
+// e.g. resettable dynstats buckets, never goes down otherwise. Hosts are
+// keyed by their host label value, "" when the host label is disabled.
+type restartTracker struct {
+	lock sync.Mutex
+	// monotonic adds up counters across restarts instead of exporting
+	// them as reported.
+	monotonic bool
+	// modes tells which counters are reported as deltas.
+	modes     CounterModes
+	counters  map[string]*counterState
+	processes map[string]*processState
+	// emissions holds the absolute counters of the current emission per
+	// host, which a restart detected later in the emission applies to.
+	emissions map[string]*emissionCounters
+}
+
+// emissionCounters is the absolute counters of one impstats emission seen so far,
+// with their values before the emission.
+type emissionCounters struct {
+	at     time.Time
+	points []*model.Point
+	prev   []float64
+}
+
+func newRestartTracker() *restartTracker {
+	return &restartTracker{
+		counters:  make(map[string]*counterState),
+		processes: make(map[string]*processState),
+		emissions: make(map[string]*emissionCounters),
+	}
+}
+
+// WithMonotonicCounters makes exported counters monotonic across rsyslog
+// restarts by adding the last value before a restart to all later values,
+// so that long-range increase() queries are not distorted by short rsyslog
+// lifetimes.
+func WithMonotonicCounters(enabled bool) Option {
+	return func(e *Exporter) {
+		e.restarts.monotonic = enabled
+	}
+}
+
+// restartObjectType and restartCounters are the counters restarts are
+// detected from: the CPU time of rsyslog.
+const restartObjectType = "resource"
+
+var restartCounters = map[string]bool{
+	"resource_utime": true,
+	"resource_stime": true,
+}
+
+// track records the counters among points, emitted by host at ts. Delta
+// counters are replaced by their running totals, and in monotonic mode
+// absolute ones are adjusted. The CPU time of rsyslog going backwards
+// counts as a restart, once per emission. track reports a restart, and in
+// monotonic mode returns copies of the points of the emission handled
+// before the restart was detected, adjusted, to replace them.
+func (r *restartTracker) track(host string, points []*model.Point, ts time.Time) (bool, []*model.Point) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	proc, ok := r.processes[host]
+	if !ok {
+		// the first process seen is taken to have started with its first
+		// emission
+		proc = &processState{Start: ts}
+		r.processes[host] = proc
+	}
+	em := r.emissions[host]
+	if em == nil || !sameEmission(em.at, ts) {
+		em = &emissionCounters{at: ts}
+		r.emissions[host] = em
+	}
+
+	var adjusted []*model.Point
+	restarted := r.restartIn(points) && !sameEmission(proc.LastRestart, ts)
+	if restarted {
+		proc.Restarts++
+		proc.Start = ts
+		proc.LastRestart = ts
+		adjusted = r.settle(em, proc.Restarts)
+	}
+
+	for _, p := range points {
+		if p.Type != model.Counter {
+			continue
+		}
+		key := p.Key()
+		c, ok := r.counters[key]
+		if !ok {
+			c = &counterState{Raw: p.Value, Restarts: proc.Restarts}
+			r.counters[key] = c
+		}
+		if r.modes.mode(p.ObjectType) == CounterDelta {
+			c.Raw = p.Value
+			c.Offset += p.Value
+			p.Value = c.Offset
+			continue
+		}
+		if c.Restarts < proc.Restarts {
+			// the first value since the restart: the previous process
+			// ended with the last value seen
+			c.Offset += c.Raw
+			c.Restarts = proc.Restarts
+		}
+		em.points = append(em.points, p)
+		em.prev = append(em.prev, c.Raw)
+		c.Raw = p.Value
+		if r.monotonic {
+			p.Value += c.Offset
+		}
+	}
+	return restarted, adjusted
+}
+
+// settle accounts for a restart detected in em in the counters handled
+// earlier in em, which were still taken to belong to the previous process,
+// and returns their adjusted points in monotonic mode.
+func (r *restartTracker) settle(em *emissionCounters, restarts int64) []*model.Point {
+	var adjusted []*model.Point
+	for i, p := range em.points {
+		c := r.counters[p.Key()]
+		if c == nil || c.Restarts >= restarts {
+			continue
+		}
+		c.Offset += em.prev[i]
+		c.Restarts = restarts
+		if r.monotonic {
+			next := *p
+			next.Value = c.Raw + c.Offset
+			adjusted = append(adjusted, &next)
+		}
+	}
+	return adjusted
+}
+
+// restartIn reports whether the CPU time among points went backwards.
+func (r *restartTracker) restartIn(points []*model.Point) bool {
+	for _, p := range points {
+		if p.ObjectType != restartObjectType || !restartCounters[p.Name] ||
+			r.modes.mode(p.ObjectType) == CounterDelta {
+			continue
+		}
+		if c, ok := r.counters[p.Key()]; ok && p.Value < c.Raw {
+			return true
+		}
+	}
+	return false
+}
+
+// sameEmission reports whether two stats timestamps belong to the same
+// impstats emission.
+func sameEmission(a, b time.Time) bool {
+	if a.IsZero() {
+		return false
+	}
+	d := b.Sub(a)
+	return d > -batchGap && d < batchGap
+}
+
+// forget drops the counter state of key, e.g. when its series expired.
+func (r *restartTracker) forget(key string) {
+	r.lock.Lock()
+	delete(r.counters, key)
+	r.lock.Unlock()
+}
+
+// state returns copies of the counter and process states for persistence.
+func (r *restartTracker) state() (map[string]*counterState, map[string]*processState) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	counters := make(map[string]*counterState, len(r.counters))
+	for k, c := range r.counters {
+		cc := *c
+		counters[k] = &cc
+	}
+	processes := make(map[string]*processState, len(r.processes))
+	for k, p := range r.processes {
+		pc := *p
+		processes[k] = &pc
+	}
+	return counters, processes
+}
+
+// restore takes over persisted counter and process states.
+func (r *restartTracker) restore(counters map[string]*counterState, processes map[string]*processState) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for k, c := range counters {
+		if c != nil {
+			r.counters[k] = c
+		}
+	}
+	for k, p := range processes {
+		if p != nil {
+			r.processes[k] = p
+		}
+	}
+}
+
+// restartDescs returns the descriptors of the restart metrics for host.
+func restartDescs(host string) (restarts, start *prometheus.Desc) {
+	labels := hostLabelNames(host)
+	restarts = prometheus.NewDesc(
+		prometheus.BuildFQName("", "rsyslog", "restarts_total"),
+		"Restarts of rsyslog detected from its CPU time going backwards",
+		labels, nil,
+	)
+	start = prometheus.NewDesc(
+		prometheus.BuildFQName("", "rsyslog", "process_start_time_seconds"),
+		"Estimated start time of rsyslog: its first stats emission after the last detected restart, in seconds since the epoch",
+		labels, nil,
+	)
+	return restarts, start
+}
+
+func (r *restartTracker) describe(ch chan<- *prometheus.Desc) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for host := range r.processes {
+		restarts, start := restartDescs(host)
+		ch <- restarts
+		ch <- start
+	}
+}
+
+// collect sends the restart metrics of all hosts to ch and returns the
+// number of metrics that could not be created. The start time is only sent
+// once a restart was seen, as the first emission seen of a process is not
+// its start.
+func (r *restartTracker) collect(ch chan<- prometheus.Metric) int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	skipped := 0
+	for host, proc := range r.processes {
+		restarts, start := restartDescs(host)
+		hostValue := hostLabelValues(host)
+		send := func(desc *prometheus.Desc, typ prometheus.ValueType, v float64) {
+			metric, err := prometheus.NewConstMetric(desc, typ, v, hostValue...)
+			if err != nil {
+				skipped++
+				return
+			}
+			ch <- metric
+		}
+		send(restarts, prometheus.CounterValue, float64(proc.Restarts))
+		if proc.Restarts > 0 {
+			send(start, prometheus.GaugeValue, float64(proc.Start.UnixNano())/1e9)
+		}
+	}
+	return skipped
+}
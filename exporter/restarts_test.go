@@ -0,0 +1,138 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// emission feeds one impstats emission at offset d with the given queue
+// enqueued and resource utime values.
+func emission(t *testing.T, re *Exporter, d time.Duration, queued, utime int) {
+	t.Helper()
+	prefix := stamp(d) + " host rsyslogd-pstats: "
+	for _, payload := range []string{
+		`{"name":"main Q","enqueued":` + strconv.Itoa(queued) + `}`,
+		`{"name":"resource-usage","utime":` + strconv.Itoa(utime) + `}`,
+	} {
+		if err := re.handleStatLine([]byte(prefix + payload)); err != nil {
+			t.Fatalf(handleStatLineFailMsg, err)
+		}
+	}
+}
+
+func TestRestartDetection(t *testing.T) {
+	re := New()
+	emission(t, re, 0, 100, 5000)
+	emission(t, re, 10*time.Second, 150, 6000)
+	proc := re.restarts.processes[""]
+	if proc.Restarts != 0 {
+		t.Fatalf("expected no restart for growing counters, got %d", proc.Restarts)
+	}
+	if !proc.Start.Equal(freshnessStart) {
+		t.Fatalf("want start %v, got %v", freshnessStart, proc.Start)
+	}
+	// the first emission seen is not the start of rsyslog
+	ch := make(chan prometheus.Metric, 2)
+	re.restarts.collect(ch)
+	if len(ch) != 1 {
+		t.Fatalf("expected only restarts before a restart, got %d metrics", len(ch))
+	}
+	<-ch
+
+	// both counters go backwards, but it is one restart
+	emission(t, re, 20*time.Second, 10, 100)
+	if proc.Restarts != 1 {
+		t.Fatalf("expected one restart, got %d", proc.Restarts)
+	}
+	if want := freshnessStart.Add(20 * time.Second); !proc.Start.Equal(want) {
+		t.Fatalf("want start %v, got %v", want, proc.Start)
+	}
+	// values are exported as reported
+	if got := enqueued(t, re, "main Q"); got != 10 {
+		t.Fatalf("want enqueued 10, got %d", got)
+	}
+
+	emission(t, re, 30*time.Second, 5, 50)
+	if proc.Restarts != 2 {
+		t.Fatalf("expected a second restart, got %d", proc.Restarts)
+	}
+
+	re.restarts.collect(ch)
+	if len(ch) != 2 {
+		t.Fatalf("expected restarts and start time, got %d metrics", len(ch))
+	}
+}
+
+func TestMonotonicCounters(t *testing.T) {
+	re := New(WithMonotonicCounters(true))
+	emission(t, re, 0, 100, 5000)
+	emission(t, re, 10*time.Second, 150, 6000)
+	emission(t, re, 20*time.Second, 10, 100)
+	if got := enqueued(t, re, "main Q"); got != 160 {
+		t.Fatalf("want enqueued 150+10, got %d", got)
+	}
+	emission(t, re, 30*time.Second, 30, 200)
+	if got := enqueued(t, re, "main Q"); got != 180 {
+		t.Fatalf("want enqueued 150+30, got %d", got)
+	}
+	// gauges are never adjusted
+	p, err := findPoint(re, "queue_size", "queue", "main Q")
+	if err != nil || p.Value != 0 {
+		t.Fatalf("want queue size 0, got %v (err %v)", p.Value, err)
+	}
+}
+
+func TestResettableDynstatsAreNoRestart(t *testing.T) {
+	re := New(WithMonotonicCounters(true))
+	for i, v := range []int{5, 3, 7, 2, 4, 1} {
+		d := time.Duration(i) * 10 * time.Second
+		line := stamp(d) + ` host rsyslogd-pstats: {"name":"msg_per_host","origin":"dynstats.bucket","values":{"host-a":` + strconv.Itoa(v) + `}}`
+		if err := re.handleStatLine([]byte(line)); err != nil {
+			t.Fatalf(handleStatLineFailMsg, err)
+		}
+		emission(t, re, d, 100*(i+1), 1000*(i+1))
+	}
+	proc := re.restarts.processes[""]
+	if proc.Restarts != 0 {
+		t.Fatalf("expected no restart for a resettable bucket, got %d", proc.Restarts)
+	}
+	if !proc.Start.Equal(freshnessStart) {
+		t.Fatalf("want start %v, got %v", freshnessStart, proc.Start)
+	}
+	// without a restart the bucket is exported as reported
+	p, err := findPoint(re, "dynstat_msg_per_host", "counter", "host-a")
+	if err != nil || p.Value != 1 {
+		t.Fatalf("want bucket counter 1, got %v (err %v)", p.Value, err)
+	}
+	if got := enqueued(t, re, "main Q"); got != 600 {
+		t.Fatalf("want enqueued 600, got %d", got)
+	}
+}
+
+func TestSameEmission(t *testing.T) {
+	if sameEmission(time.Time{}, freshnessStart) {
+		t.Errorf("zero time never belongs to an emission")
+	}
+	if !sameEmission(freshnessStart, freshnessStart.Add(3*time.Millisecond)) {
+		t.Errorf("expected lines milliseconds apart to be one emission")
+	}
+	if sameEmission(freshnessStart, freshnessStart.Add(-time.Second)) {
+		t.Errorf("expected lines a second apart to be separate emissions")
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/prometheus-community/rsyslog_exporter/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// skippedSeriesDesc describes the number of series left out of a scrape.
+var skippedSeriesDesc = prometheus.NewDesc(
+	prometheus.BuildFQName("", "rsyslog", "skipped_series"),
+	"series not exported because of an invalid name or label, or a collision with another series after sanitizing",
+	nil, nil,
+)
+
+// family is a metric family of the store: the points of one exported name,
+// which must share help, type and label names.
+type family struct {
+	desc   *prometheus.Desc
+	help   string
+	typ    model.PointType
+	labels []string
+}
+
+func newFamily(p *model.Point) *family {
+	return &family{desc: p.PromDescription(), help: p.Description, typ: p.Type, labels: p.PromLabelNames()}
+}
+
+// matches reports whether p belongs to f.
+func (f *family) matches(p *model.Point) bool {
+	n := len(p.Labels)
+	if p.Host != "" {
+		n++
+	}
+	if f.help != p.Description || f.typ != p.Type || len(f.labels) != n {
+		return false
+	}
+	for i, l := range p.Labels {
+		if f.labels[i] != model.SanitizeLabelName(l.Name) {
+			return false
+		}
+	}
+	return p.Host == "" || f.labels[n-1] == model.HostLabelName
+}
+
+// scrapeCache renders the points of the store into metrics. Descriptors are
+// kept per metric family, and the rendered metrics are reused by every
+// scrape until the store changes, so that scrapes between two impstats
+// emissions cost next to nothing. A series that cannot be exported is
+// skipped rather than failing the scrape, and counted in
+// rsyslog_skipped_series.
+type scrapeCache struct {
+	lock sync.Mutex
+	// rendered tells whether metrics and descs were rendered at gen.
+	rendered bool
+	gen      uint64
+	// families holds the families of the last rendering by exported name.
+	families map[string]*family
+	// skipped holds the store keys of the series skipped by the last
+	// rendering, so that each is logged once.
+	skipped map[string]bool
+	metrics []prometheus.Metric
+	descs   []*prometheus.Desc
+}
+
+// render returns the metrics of all points of s, the descriptors of their
+// families and the number of series skipped, from a single snapshot of s.
+// The returned slices must not be modified.
+func (c *scrapeCache) render(s *model.Store, logger *log.Logger) ([]prometheus.Metric, []*prometheus.Desc, int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	// the generation is read before the snapshot: should the store change
+	// in between, the next scrape renders again
+	gen := s.Generation()
+	if c.rendered && gen == c.gen {
+		return c.metrics, c.descs, len(c.skipped)
+	}
+	points := s.Snapshot()
+	points = append(points, objectCounts(points)...)
+	families := make(map[string]*family, len(c.families))
+	skipped := make(map[string]bool, len(c.skipped))
+	// series identifies the exported series, which may collide once
+	// sanitized although their store keys differ
+	series := make(map[string]bool, len(points))
+	// the previous slices may still be read by a concurrent scrape
+	metrics := make([]prometheus.Metric, 0, len(points))
+	descs := make([]*prometheus.Desc, 0, len(c.descs))
+	// described holds the families with a valid descriptor, which are
+	// only known once one of their metrics was created
+	described := make(map[*family]bool, len(c.families))
+	skip := func(p *model.Point, err error) {
+		key := p.Key()
+		if !c.skipped[key] {
+			logger.Printf("skipping series %s: %v", key, err)
+		}
+		skipped[key] = true
+	}
+	for _, p := range points {
+		name := p.PromName()
+		f, ok := families[name]
+		if !ok {
+			if f = c.families[name]; f == nil || !f.matches(p) {
+				f = newFamily(p)
+			}
+			families[name] = f
+		} else if !f.matches(p) {
+			skip(p, fmt.Errorf("help, type or labels differ from other series of %s", name))
+			continue
+		}
+		values := p.PromLabelValues()
+		id := name + "\xff" + strings.Join(values, "\xff")
+		if series[id] {
+			skip(p, fmt.Errorf("collides with another series of %s", name))
+			continue
+		}
+		m, err := prometheus.NewConstMetric(f.desc, p.PromType(), p.PromValue(), values...)
+		if err != nil {
+			skip(p, err)
+			continue
+		}
+		series[id] = true
+		metrics = append(metrics, m)
+		if !described[f] {
+			described[f] = true
+			descs = append(descs, f.desc)
+		}
+	}
+
+	c.rendered, c.gen = true, gen
+	c.families, c.skipped = families, skipped
+	c.metrics, c.descs = metrics, descs
+	return metrics, descs, len(skipped)
+}
@@ -0,0 +1,91 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// MaxFrameSize bounds the size of a single syslog frame. impstats objects
+// with many dynstats counters can get long, so this is well above the
+// classic 2 KiB syslog limit.
+const MaxFrameSize = 1 << 20
+
+var ErrFrameTooLarge = errors.New("syslog frame exceeds maximum size")
+
+// maxOctetCountDigits is the number of digits needed for MaxFrameSize.
+const maxOctetCountDigits = 7
+
+// ScanFrames is a bufio.SplitFunc for syslog over TCP as described in
+// RFC 6587. Each frame is detected independently: frames starting with a
+// digit use octet counting ("MSG-LEN SP SYSLOG-MSG"), all others use
+// non-transparent framing terminated by LF. Stray CR/LF between frames is
+// skipped.
+func ScanFrames(data []byte, atEOF bool) (int, []byte, error) {
+	start := 0
+	for start < len(data) && (data[start] == '\n' || data[start] == '\r') {
+		start++
+	}
+	if start == len(data) {
+		return start, nil, nil
+	}
+
+	if n, hdr, ok := octetCount(data[start:]); ok {
+		if n > MaxFrameSize {
+			return 0, nil, fmt.Errorf("%w: %d bytes", ErrFrameTooLarge, n)
+		}
+		end := start + hdr + n
+		if end > len(data) {
+			if atEOF {
+				return 0, nil, io.ErrUnexpectedEOF
+			}
+			return start, nil, nil
+		}
+		return end, data[start+hdr : end], nil
+	}
+
+	if i := bytes.IndexByte(data[start:], '\n'); i >= 0 {
+		return start + i + 1, bytes.TrimSuffix(data[start:start+i], []byte{'\r'}), nil
+	}
+	if atEOF {
+		return len(data), data[start:], nil
+	}
+	return start, nil, nil
+}
+
+// octetCount reports whether b starts with an RFC 6587 octet count, i.e. a
+// run of digits followed by a space, and returns the message length and the
+// length of the count including the space. Digits whose trailing space has
+// not arrived yet are not a count; the LF path then waits for more data.
+func octetCount(b []byte) (int, int, bool) {
+	n := 0
+	for i, c := range b {
+		switch {
+		case c >= '0' && c <= '9':
+			if i >= maxOctetCountDigits {
+				// more digits than any valid frame needs: not a count.
+				return 0, 0, false
+			}
+			n = n*10 + int(c-'0')
+		case c == ' ' && i > 0:
+			return n, i + 1, true
+		default:
+			return 0, 0, false
+		}
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,87 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func scanAll(t *testing.T, r io.Reader) ([]string, error) {
+	t.Helper()
+	scanner := bufio.NewScanner(r)
+	scanner.Split(ScanFrames)
+	var frames []string
+	for scanner.Scan() {
+		frames = append(frames, scanner.Text())
+	}
+	return frames, scanner.Err()
+}
+
+func TestScanFramesMixed(t *testing.T) {
+	in := "11 <46>hello\nx" + // octet counted, LF inside the frame
+		"<46>lf framed\r\n" +
+		"\n\n" + // stray separators
+		"5 <46>a" +
+		"2025-03-04T11:59:58Z host tag: {}\n" + // digits but not a count
+		"<46>no trailing newline"
+	// deliver one byte at a time to exercise partial frames
+	frames, err := scanAll(t, iotest.OneByteReader(strings.NewReader(in)))
+	if err != nil {
+		t.Fatalf("unexpected scan error: %v", err)
+	}
+	want := []string{
+		"<46>hello\nx",
+		"<46>lf framed",
+		"<46>a",
+		"2025-03-04T11:59:58Z host tag: {}",
+		"<46>no trailing newline",
+	}
+	if len(frames) != len(want) {
+		t.Fatalf("wanted %d frames, got %d: %q", len(want), len(frames), frames)
+	}
+	for i := range want {
+		if frames[i] != want[i] {
+			t.Errorf("frame %d: wanted %q, got %q", i, want[i], frames[i])
+		}
+	}
+}
+
+func TestScanFramesTruncatedOctetCount(t *testing.T) {
+	_, err := scanAll(t, strings.NewReader("20 <46>short"))
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
+
+func TestScanFramesTooLarge(t *testing.T) {
+	_, err := scanAll(t, strings.NewReader("9999999 <46>x"))
+	if !errors.Is(err, ErrFrameTooLarge) {
+		t.Fatalf("expected ErrFrameTooLarge, got %v", err)
+	}
+}
+
+func TestScanFramesLongDigitRun(t *testing.T) {
+	frames, err := scanAll(t, strings.NewReader("123456789 not a count\n"))
+	if err != nil {
+		t.Fatalf("unexpected scan error: %v", err)
+	}
+	if len(frames) != 1 || frames[0] != "123456789 not a count" {
+		t.Fatalf("unexpected frames: %q", frames)
+	}
+}
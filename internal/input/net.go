@@ -0,0 +1,177 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+)
+
+// maxDatagramSize is the largest payload a UDP datagram can carry.
+const maxDatagramSize = 65535
+
+// now is the clock used to complete RFC 3164 timestamps; tests may override it.
+var now = time.Now
+
+// UDPSource receives syslog messages over UDP, one message per datagram,
+// as sent by rsyslog's omfwd with protocol="udp".
+type UDPSource struct {
+	addr   string
+	logger *log.Logger
+	// onListen is called with the bound address once listening; test hook.
+	onListen func(net.Addr)
+}
+
+// NewUDPSource returns a Source listening for syslog datagrams on addr.
+func NewUDPSource(addr string) *UDPSource {
+	return &UDPSource{addr: addr, logger: log.Default()}
+}
+
+// SetLogger directs the log output of s to logger.
+func (s *UDPSource) SetLogger(logger *log.Logger) {
+	s.logger = logger
+}
+
+func (s *UDPSource) Run(ctx context.Context, lines chan<- []byte) error {
+	var lc net.ListenConfig
+	conn, err := lc.ListenPacket(ctx, "udp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on udp %s: %w", s.addr, err)
+	}
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer func() {
+		stop()
+		_ = conn.Close()
+	}()
+	if s.onListen != nil {
+		s.onListen(conn.LocalAddr())
+	}
+	s.logger.Printf("Receiving stats on udp %s", conn.LocalAddr())
+
+	return packetLoop(ctx, conn, lines)
+}
+
+// packetLoop reads one syslog message per datagram from conn until ctx is
+// canceled or reading fails.
+func packetLoop(ctx context.Context, conn net.PacketConn, lines chan<- []byte) error {
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("failed to read from %s: %w", conn.LocalAddr(), err)
+		}
+		line := toLine(buf[:n], now())
+		if line == nil {
+			continue
+		}
+		if !send(ctx, lines, line) {
+			return ctx.Err()
+		}
+	}
+}
+
+// TCPSource receives syslog messages over TCP, as sent by rsyslog's omfwd
+// with protocol="tcp". Both octet-counted and LF-delimited framing are
+// accepted, see ScanFrames.
+type TCPSource struct {
+	addr   string
+	logger *log.Logger
+	// onListen is called with the bound address once listening; test hook.
+	onListen func(net.Addr)
+}
+
+// NewTCPSource returns a Source accepting syslog connections on addr.
+func NewTCPSource(addr string) *TCPSource {
+	return &TCPSource{addr: addr, logger: log.Default()}
+}
+
+// SetLogger directs the log output of s to logger.
+func (s *TCPSource) SetLogger(logger *log.Logger) {
+	s.logger = logger
+}
+
+func (s *TCPSource) Run(ctx context.Context, lines chan<- []byte) error {
+	var lc net.ListenConfig
+	ln, err := lc.Listen(ctx, "tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on tcp %s: %w", s.addr, err)
+	}
+	stop := context.AfterFunc(ctx, func() { _ = ln.Close() })
+	defer func() {
+		stop()
+		_ = ln.Close()
+	}()
+	if s.onListen != nil {
+		s.onListen(ln.Addr())
+	}
+	s.logger.Printf("Receiving stats on tcp %s", ln.Addr())
+
+	return acceptLoop(ctx, ln, lines, s.logger)
+}
+
+// acceptLoop serves every connection accepted on ln until ctx is canceled
+// or accepting fails, then waits for open connections to wind down. Read
+// errors are logged to logger.
+func acceptLoop(ctx context.Context, ln net.Listener, lines chan<- []byte, logger *log.Logger) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("failed to accept on %s: %w", ln.Addr(), err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serveStream(ctx, conn, lines, logger)
+		}()
+	}
+}
+
+// serveStream reads framed syslog messages from conn until EOF, a framing
+// error or cancellation of ctx, which is logged to logger.
+func serveStream(ctx context.Context, conn net.Conn, lines chan<- []byte, logger *log.Logger) {
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer func() {
+		stop()
+		_ = conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxFrameSize+maxOctetCountDigits+1)
+	scanner.Split(ScanFrames)
+	for scanner.Scan() {
+		line := toLine(scanner.Bytes(), now())
+		if line == nil {
+			continue
+		}
+		if !send(ctx, lines, line) {
+			return
+		}
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		logger.Printf("error reading stats from %s: %v", conn.RemoteAddr(), err)
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const (
+	loopbackAny    = "127.0.0.1:0"
+	lineTimeout    = 2 * time.Second
+	rfc3164Message = "<46>Mar  4 11:59:58 relay-01 rsyslogd-pstats: " + pstatsJSON
+	renderedLine   = "2025-03-04T11:59:58Z relay-01 rsyslogd-pstats: " + pstatsJSON
+)
+
+// runSource starts src and returns the line channel, the bound address and
+// a function stopping the source and returning its error.
+func runSource(t *testing.T, src Source, bound <-chan net.Addr) (<-chan []byte, net.Addr, func() error) {
+	t.Helper()
+	origNow := now
+	t.Cleanup(func() { now = origNow })
+	now = func() time.Time { return testNow }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lines := make(chan []byte, 16)
+	errC := make(chan error, 1)
+	go func() { errC <- src.Run(ctx, lines) }()
+
+	select {
+	case addr := <-bound:
+		return lines, addr, func() error {
+			cancel()
+			return <-errC
+		}
+	case err := <-errC:
+		cancel()
+		t.Fatalf("source failed to start: %v", err)
+	case <-time.After(lineTimeout):
+		cancel()
+		t.Fatalf("source did not start listening in time")
+	}
+	return nil, nil, nil
+}
+
+func expectLine(t *testing.T, lines <-chan []byte, want string) {
+	t.Helper()
+	select {
+	case got := <-lines:
+		if string(got) != want {
+			t.Fatalf("wanted line %q, got %q", want, got)
+		}
+	case <-time.After(lineTimeout):
+		t.Fatalf("timed out waiting for line %q", want)
+	}
+}
+
+func TestUDPSource(t *testing.T) {
+	bound := make(chan net.Addr, 1)
+	src := NewUDPSource(loopbackAny)
+	src.onListen = func(a net.Addr) { bound <- a }
+	lines, addr, stop := runSource(t, src, bound)
+
+	conn, err := net.Dial("udp", addr.String())
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	for _, msg := range []string{"\n", rfc3164Message + "\n"} {
+		if _, err := conn.Write([]byte(msg)); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+	expectLine(t, lines, renderedLine)
+
+	if err := stop(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled after stop, got %v", err)
+	}
+}
+
+func TestTCPSource(t *testing.T) {
+	bound := make(chan net.Addr, 1)
+	src := NewTCPSource(loopbackAny)
+	src.onListen = func(a net.Addr) { bound <- a }
+	lines, addr, stop := runSource(t, src, bound)
+
+	conn, err := net.Dial("tcp", addr.String())
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	octetCounted := "<46>1 2025-03-04T11:59:58Z relay-01 rsyslogd-pstats - - - " + pstatsJSON
+	payload := rfc3164Message + "\n" + strconv.Itoa(len(octetCounted)) + " " + octetCounted
+	if _, err := conn.Write([]byte(payload)); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	expectLine(t, lines, renderedLine)
+	expectLine(t, lines, renderedLine)
+
+	// stopping must also tear down the still open connection
+	if err := stop(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled after stop, got %v", err)
+	}
+}
+
+func TestListenErrors(t *testing.T) {
+	ctx := context.Background()
+	lines := make(chan []byte)
+	if err := NewUDPSource("256.0.0.1:0").Run(ctx, lines); err == nil {
+		t.Errorf("expected udp listen error")
+	}
+	if err := NewTCPSource("256.0.0.1:0").Run(ctx, lines); err == nil {
+		t.Errorf("expected tcp listen error")
+	}
+}
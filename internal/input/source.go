@@ -0,0 +1,162 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package input provides the sources from which the exporter reads raw
+// impstats lines: stdin (omprog), network listeners (omfwd), Unix sockets
+// (omuxsock) and impstats log files.
+package input
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"log"
+	"sync"
+)
+
+// Source delivers raw impstats lines to the exporter.
+//
+// Run sends every line on the lines channel until the input is exhausted,
+// an unrecoverable error occurs or ctx is canceled. Each slice sent must be
+// owned by the receiver; sources must not reuse it afterwards. Run must not
+// send on lines after it has returned. A nil return means the input ended
+// normally (for example EOF on stdin).
+type Source interface {
+	Run(ctx context.Context, lines chan<- []byte) error
+}
+
+// SetLogger directs the log output of src, and of the sources merged into
+// it, to logger. Sources that do not log are left alone.
+func SetLogger(src Source, logger *log.Logger) {
+	if s, ok := src.(interface{ SetLogger(*log.Logger) }); ok {
+		s.SetLogger(logger)
+	}
+}
+
+// scannerSource adapts a bufio.Scanner to the Source interface.
+type scannerSource struct {
+	scanner *bufio.Scanner
+}
+
+// NewScannerSource returns a Source reading newline separated lines from s.
+func NewScannerSource(s *bufio.Scanner) Source {
+	return &scannerSource{scanner: s}
+}
+
+func (s *scannerSource) Run(ctx context.Context, lines chan<- []byte) error {
+	for s.scanner.Scan() {
+		// copy the bytes since scanner reuses internal buffer
+		b := NewLine(s.scanner.Bytes())
+		if !send(ctx, lines, b) {
+			return ctx.Err()
+		}
+	}
+	return s.scanner.Err()
+}
+
+// multiSource runs several sources concurrently and merges their output.
+type multiSource []Source
+
+// Merge returns a Source that runs all given sources concurrently. It ends
+// once every source has ended. The first source to fail stops the others and
+// its error is returned.
+func Merge(sources ...Source) Source {
+	if len(sources) == 1 {
+		return sources[0]
+	}
+	return multiSource(sources)
+}
+
+// SetLogger directs the log output of all merged sources to logger.
+func (m multiSource) SetLogger(logger *log.Logger) {
+	for _, src := range m {
+		SetLogger(src, logger)
+	}
+}
+
+func (m multiSource) Run(ctx context.Context, lines chan<- []byte) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	for _, src := range m {
+		wg.Add(1)
+		go func(src Source) {
+			defer wg.Done()
+			if err := src.Run(ctx, lines); err != nil && !errors.Is(err, context.Canceled) {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(src)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// maxFreeLine bounds the capacity of line buffers kept for reuse, so that
+// a single overlong line does not pin its memory.
+const maxFreeLine = 64 * 1024
+
+// freeLines holds line buffers handed back by ReleaseLine.
+var freeLines = make(chan []byte, 64)
+
+// lineBuf returns an empty buffer for a line of n bytes, reusing a released
+// one if possible.
+func lineBuf(n int) []byte {
+	select {
+	case b := <-freeLines:
+		if cap(b) >= n {
+			return b[:0]
+		}
+	default:
+	}
+	return make([]byte, 0, n)
+}
+
+// NewLine returns a copy of b for sending to the exporter. Sources use it
+// so that lines released by the exporter are recycled.
+func NewLine(b []byte) []byte {
+	return append(lineBuf(len(b)), b...)
+}
+
+// ReleaseLine hands a line received from a Source back for reuse once the
+// receiver is done with it. The line must not be used afterwards.
+func ReleaseLine(line []byte) {
+	if cap(line) == 0 || cap(line) > maxFreeLine {
+		return
+	}
+	select {
+	case freeLines <- line:
+	default:
+	}
+}
+
+// send delivers b on lines unless ctx is canceled first. It reports whether
+// the line was delivered.
+func send(ctx context.Context, lines chan<- []byte, b []byte) bool {
+	select {
+	case lines <- b:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// funcSource adapts a function to the Source interface.
+type funcSource func(ctx context.Context, lines chan<- []byte) error
+
+func (f funcSource) Run(ctx context.Context, lines chan<- []byte) error { return f(ctx, lines) }
+
+func collect(t *testing.T, src Source) ([]string, error) {
+	t.Helper()
+	lines := make(chan []byte)
+	errC := make(chan error, 1)
+	go func() {
+		defer close(lines)
+		errC <- src.Run(context.Background(), lines)
+	}()
+	var got []string
+	for l := range lines {
+		got = append(got, string(l))
+	}
+	return got, <-errC
+}
+
+func TestScannerSource(t *testing.T) {
+	src := NewScannerSource(bufio.NewScanner(strings.NewReader("a\nb\n")))
+	got, err := collect(t, src)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(got, ",") != "a,b" {
+		t.Fatalf("unexpected lines: %q", got)
+	}
+}
+
+func TestScannerSourceCanceled(t *testing.T) {
+	src := NewScannerSource(bufio.NewScanner(strings.NewReader("a\n")))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := src.Run(ctx, make(chan []byte)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestMergeSingle(t *testing.T) {
+	src := NewScannerSource(bufio.NewScanner(strings.NewReader("")))
+	if Merge(src) != src {
+		t.Fatalf("merging a single source should return it unchanged")
+	}
+}
+
+func TestMergeCombinesLines(t *testing.T) {
+	src := Merge(
+		NewScannerSource(bufio.NewScanner(strings.NewReader("a\n"))),
+		NewScannerSource(bufio.NewScanner(strings.NewReader("b\n"))),
+	)
+	got, err := collect(t, src)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sort.Strings(got)
+	if strings.Join(got, ",") != "a,b" {
+		t.Fatalf("unexpected lines: %q", got)
+	}
+}
+
+func TestMergeStopsOnFirstError(t *testing.T) {
+	boom := errors.New("boom")
+	blocking := funcSource(func(ctx context.Context, _ chan<- []byte) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	failing := funcSource(func(context.Context, chan<- []byte) error { return boom })
+
+	if _, err := collect(t, Merge(blocking, failing)); !errors.Is(err, boom) {
+		t.Fatalf("expected %v, got %v", boom, err)
+	}
+}
+
+func TestSetLogger(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	udp, tcp := NewUDPSource(":0"), NewTCPSource(":0")
+	file := NewFileSource("stats.log", "", 0)
+	unix, err := NewUnixSource("stats.sock", UnixStream)
+	if err != nil {
+		t.Fatalf("NewUnixSource failed: %v", err)
+	}
+	SetLogger(Merge(udp, tcp, unix, file), logger)
+	if udp.logger != logger || tcp.logger != logger || unix.logger != logger || file.logger != logger {
+		t.Errorf("expected every merged source to log to the given logger")
+	}
+	// sources that do not log are left alone
+	SetLogger(NewScannerSource(bufio.NewScanner(strings.NewReader(""))), logger)
+}
+
+func TestReleaseLine(t *testing.T) {
+	line := NewLine([]byte("first line"))
+	if string(line) != "first line" {
+		t.Fatalf("unexpected line %q", line)
+	}
+	ReleaseLine(line)
+	// the released buffer is reused for the next line that fits
+	next := NewLine([]byte("second"))
+	if string(next) != "second" {
+		t.Fatalf("unexpected line %q", next)
+	}
+	if &next[0] != &line[0] {
+		t.Errorf("expected the released buffer to be reused")
+	}
+	// oversized buffers are not kept
+	ReleaseLine(make([]byte, maxFreeLine+1))
+	if b := lineBuf(1); cap(b) > maxFreeLine {
+		t.Errorf("expected oversized buffer to be dropped")
+	}
+}
@@ -0,0 +1,272 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrMissingPriority = errors.New("missing syslog priority")
+	ErrInvalidHeader   = errors.New("invalid syslog header")
+)
+
+// rfc3164Stamp is the BSD syslog timestamp layout; it carries no year.
+const rfc3164Stamp = time.Stamp
+
+// utf8BOM may prefix the MSG part of RFC 5424 messages.
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// Message is a syslog message with its header fields parsed.
+type Message struct {
+	Timestamp time.Time
+	Hostname  string
+	Tag       string
+	Content   []byte
+}
+
+// ParseMessage parses an RFC 5424 or RFC 3164 syslog message. now is used to
+// complete RFC 3164 timestamps, which lack a year.
+func ParseMessage(b []byte, now time.Time) (*Message, error) {
+	rest, err := skipPriority(b)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(rest, []byte("1 ")) {
+		return parseRFC5424(rest[2:])
+	}
+	return parseRFC3164(rest, now)
+}
+
+// skipPriority strips the leading "<PRI>" from b.
+func skipPriority(b []byte) ([]byte, error) {
+	if len(b) < 3 || b[0] != '<' {
+		return nil, ErrMissingPriority
+	}
+	end := bytes.IndexByte(b, '>')
+	if end < 2 || end > 4 {
+		return nil, ErrMissingPriority
+	}
+	pri := 0
+	for _, c := range b[1:end] {
+		if c < '0' || c > '9' {
+			return nil, ErrMissingPriority
+		}
+		pri = pri*10 + int(c-'0')
+	}
+	if pri > 191 {
+		return nil, fmt.Errorf("%w: priority %d out of range", ErrMissingPriority, pri)
+	}
+	return b[end+1:], nil
+}
+
+// parseRFC5424 parses "TIMESTAMP HOSTNAME APP-NAME PROCID MSGID SD [MSG]",
+// i.e. an RFC 5424 message following "<PRI>1 ".
+func parseRFC5424(b []byte) (*Message, error) {
+	var fields [5][]byte
+	for i := range fields {
+		sp := bytes.IndexByte(b, ' ')
+		if sp <= 0 {
+			return nil, fmt.Errorf("%w: truncated RFC 5424 header", ErrInvalidHeader)
+		}
+		fields[i], b = b[:sp], b[sp+1:]
+	}
+
+	m := &Message{
+		Hostname: nilValue(fields[1]),
+		Tag:      nilValue(fields[2]),
+	}
+	if ts := nilValue(fields[0]); ts != "" {
+		t, err := time.Parse(time.RFC3339Nano, ts)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidHeader, err)
+		}
+		m.Timestamp = t
+	}
+
+	n, err := structuredDataLen(b)
+	if err != nil {
+		return nil, err
+	}
+	b = b[n:]
+	if len(b) > 0 && b[0] == ' ' {
+		b = b[1:]
+	}
+	m.Content = bytes.TrimPrefix(b, utf8BOM)
+	return m, nil
+}
+
+// structuredDataLen returns the length of the STRUCTURED-DATA element at the
+// start of b, which is either the nil value "-" or one or more
+// "[id param="value" ...]" elements.
+func structuredDataLen(b []byte) (int, error) {
+	if len(b) > 0 && b[0] == '-' {
+		return 1, nil
+	}
+	i := 0
+	for i < len(b) && b[i] == '[' {
+		end, err := sdElementEnd(b, i)
+		if err != nil {
+			return 0, err
+		}
+		i = end + 1
+	}
+	if i == 0 {
+		return 0, fmt.Errorf("%w: missing structured data", ErrInvalidHeader)
+	}
+	return i, nil
+}
+
+// sdElementEnd returns the index of the "]" closing the SD-ELEMENT that
+// starts at b[start], honouring quoted and escaped parameter values.
+func sdElementEnd(b []byte, start int) (int, error) {
+	quoted := false
+	for i := start + 1; i < len(b); i++ {
+		switch {
+		case quoted && b[i] == '\\':
+			i++ // skip the escaped character
+		case b[i] == '"':
+			quoted = !quoted
+		case !quoted && b[i] == ']':
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: unterminated structured data", ErrInvalidHeader)
+}
+
+// parseRFC3164 parses "TIMESTAMP HOSTNAME TAG: MSG", i.e. an RFC 3164 message
+// following "<PRI>". Besides the classic "Mmm dd hh:mm:ss" stamp, rsyslog's
+// RFC 3339 timestamps (RSYSLOG_ForwardFormat) are accepted as well.
+func parseRFC3164(b []byte, now time.Time) (*Message, error) {
+	m := &Message{}
+
+	if len(b) >= len(rfc3164Stamp) {
+		if t, err := ParseStamp(string(b[:len(rfc3164Stamp)]), now); err == nil {
+			m.Timestamp = t
+			b = b[len(rfc3164Stamp):]
+		}
+	}
+	if m.Timestamp.IsZero() {
+		sp := bytes.IndexByte(b, ' ')
+		if sp <= 0 {
+			return nil, fmt.Errorf("%w: missing timestamp", ErrInvalidHeader)
+		}
+		t, err := time.Parse(time.RFC3339Nano, string(b[:sp]))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidHeader, err)
+		}
+		m.Timestamp = t
+		b = b[sp:]
+	}
+
+	b = bytes.TrimLeft(b, " ")
+	sp := bytes.IndexByte(b, ' ')
+	if sp <= 0 {
+		return nil, fmt.Errorf("%w: missing hostname", ErrInvalidHeader)
+	}
+	m.Hostname, b = string(b[:sp]), b[sp+1:]
+
+	// The tag ends at the first colon or space; rsyslog limits it to 32
+	// characters but longer tags are tolerated here. A JSON payload directly
+	// following the hostname means the tag was left out.
+	end := bytes.IndexAny(b, ": ")
+	if end < 0 || bytes.ContainsAny(b[:end], `{"`) {
+		m.Content = b
+		return m, nil
+	}
+	m.Tag = string(b[:end])
+	if b[end] == ':' {
+		end++
+	}
+	m.Content = bytes.TrimLeft(b[end:], " ")
+	return m, nil
+}
+
+// ParseStamp parses the RFC 3164 timestamp s, e.g. "Oct 16 13:59:47", in
+// the location of now and the year closest to now.
+func ParseStamp(s string, now time.Time) (time.Time, error) {
+	t, err := time.ParseInLocation(rfc3164Stamp, s, now.Location())
+	if err != nil {
+		return time.Time{}, err
+	}
+	return completeYear(t, now), nil
+}
+
+// completeYear places a year-less RFC 3164 timestamp into the year closest
+// to now, so that messages from late December received in January are not
+// dated a year into the future.
+func completeYear(t, now time.Time) time.Time {
+	t = t.AddDate(now.Year(), 0, 0)
+	if t.After(now.Add(24 * time.Hour)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+	return t
+}
+
+// nilValue maps the RFC 5424 NILVALUE "-" to the empty string.
+func nilValue(b []byte) string {
+	if len(b) == 1 && b[0] == '-' {
+		return ""
+	}
+	return string(b)
+}
+
+// Line renders m in the "TIMESTAMP HOSTNAME TAG: MSG" layout that rsyslog's
+// omprog hands to the exporter, so network input shares the stdin path.
+// Missing header fields are rendered as "-".
+func (m *Message) Line() []byte {
+	ts := "-"
+	if !m.Timestamp.IsZero() {
+		ts = m.Timestamp.Format(time.RFC3339Nano)
+	}
+	host := m.Hostname
+	if host == "" {
+		host = "-"
+	}
+	tag := m.Tag
+	if tag == "" {
+		tag = "-"
+	}
+
+	line := lineBuf(len(ts) + len(host) + len(tag) + len(m.Content) + 4)
+	line = append(line, ts...)
+	line = append(line, ' ')
+	line = append(line, host...)
+	line = append(line, ' ')
+	line = append(line, tag...)
+	line = append(line, ':', ' ')
+	line = append(line, m.Content...)
+	return line
+}
+
+// toLine converts a received syslog message into an exporter line. Messages
+// without a syslog header, or with one that cannot be parsed, are passed on
+// verbatim so that plain impstats lines can be sent as well; malformed input
+// then surfaces as a stats line error in the exporter. The returned slice is
+// owned by the caller, see NewLine. Empty messages yield nil.
+func toLine(b []byte, now time.Time) []byte {
+	b = bytes.TrimRight(b, "\r\n\x00")
+	if len(b) == 0 {
+		return nil
+	}
+	if b[0] == '<' {
+		if m, err := ParseMessage(b, now); err == nil {
+			return m.Line()
+		}
+	}
+	return NewLine(b)
+}
@@ -0,0 +1,145 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	th "github.com/prometheus-community/rsyslog_exporter/internal/testhelpers"
+)
+
+const pstatsJSON = `{"name":"main Q","size":1}`
+
+var testNow = time.Date(2025, time.March, 4, 12, 0, 0, 0, time.UTC)
+
+func TestParseMessageRFC3164(t *testing.T) {
+	m, err := ParseMessage([]byte("<46>Mar  4 11:59:58 relay-01 rsyslogd-pstats: "+pstatsJSON), testNow)
+	if err != nil {
+		t.Fatalf("ParseMessage failed: %v", err)
+	}
+	want := time.Date(2025, time.March, 4, 11, 59, 58, 0, time.UTC)
+	if !m.Timestamp.Equal(want) {
+		t.Errorf("wanted timestamp %v, got %v", want, m.Timestamp)
+	}
+	th.AssertEqString(t, "hostname", "relay-01", m.Hostname)
+	th.AssertEqString(t, "tag", "rsyslogd-pstats", m.Tag)
+	th.AssertEqString(t, "content", pstatsJSON, string(m.Content))
+}
+
+func TestParseMessageRFC3164RFC3339Timestamp(t *testing.T) {
+	m, err := ParseMessage([]byte("<46>2025-03-04T11:59:58.123456+00:00 relay-01 rsyslogd-pstats: "+pstatsJSON), testNow)
+	if err != nil {
+		t.Fatalf("ParseMessage failed: %v", err)
+	}
+	th.AssertEqString(t, "timestamp", "2025-03-04T11:59:58.123456Z", m.Timestamp.Format(time.RFC3339Nano))
+	th.AssertEqString(t, "content", pstatsJSON, string(m.Content))
+}
+
+func TestParseMessageRFC3164PreviousYear(t *testing.T) {
+	jan := time.Date(2025, time.January, 1, 0, 0, 5, 0, time.UTC)
+	m, err := ParseMessage([]byte("<46>Dec 31 23:59:58 relay-01 rsyslogd-pstats: "+pstatsJSON), jan)
+	if err != nil {
+		t.Fatalf("ParseMessage failed: %v", err)
+	}
+	if m.Timestamp.Year() != 2024 {
+		t.Errorf("expected december message to be dated 2024, got %v", m.Timestamp)
+	}
+}
+
+func TestParseMessageRFC5424(t *testing.T) {
+	cases := []struct {
+		name string
+		msg  string
+	}{
+		{"nil structured data", "<46>1 2025-03-04T11:59:58.5Z relay-01 rsyslogd-pstats - - - " + pstatsJSON},
+		{"structured data", `<46>1 2025-03-04T11:59:58.5Z relay-01 rsyslogd-pstats 42 ID7 [ex@1 a="x\]y" b="\"q\""][ex@2 c="d"] ` + pstatsJSON},
+		{"bom", "<46>1 2025-03-04T11:59:58.5Z relay-01 rsyslogd-pstats - - - \xef\xbb\xbf" + pstatsJSON},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			m, err := ParseMessage([]byte(c.msg), testNow)
+			if err != nil {
+				t.Fatalf("ParseMessage failed: %v", err)
+			}
+			th.AssertEqString(t, "timestamp", "2025-03-04T11:59:58.5Z", m.Timestamp.Format(time.RFC3339Nano))
+			th.AssertEqString(t, "hostname", "relay-01", m.Hostname)
+			th.AssertEqString(t, "tag", "rsyslogd-pstats", m.Tag)
+			th.AssertEqString(t, "content", pstatsJSON, string(m.Content))
+		})
+	}
+}
+
+func TestParseMessageRFC5424NilValues(t *testing.T) {
+	m, err := ParseMessage([]byte("<46>1 - - - - - -"), testNow)
+	if err != nil {
+		t.Fatalf("ParseMessage failed: %v", err)
+	}
+	if !m.Timestamp.IsZero() || m.Hostname != "" || m.Tag != "" || len(m.Content) != 0 {
+		t.Fatalf("expected empty message, got %+v", m)
+	}
+	th.AssertEqString(t, "line", "- - -: ", string(m.Line()))
+}
+
+func TestParseMessageErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		msg  string
+		want error
+	}{
+		{"no priority", "Mar  4 11:59:58 relay-01 tag: x", ErrMissingPriority},
+		{"bad priority", "<4x>Mar  4 11:59:58 relay-01 tag: x", ErrMissingPriority},
+		{"priority out of range", "<192>Mar  4 11:59:58 relay-01 tag: x", ErrMissingPriority},
+		{"unterminated priority", "<46", ErrMissingPriority},
+		{"3164 bad timestamp", "<46>yesterday relay-01 tag: x", ErrInvalidHeader},
+		{"3164 no timestamp", "<46>", ErrInvalidHeader},
+		{"3164 no hostname", "<46>Mar  4 11:59:58 relay-01", ErrInvalidHeader},
+		{"5424 truncated", "<46>1 2025-03-04T11:59:58Z relay-01", ErrInvalidHeader},
+		{"5424 bad timestamp", "<46>1 yesterday relay-01 app - - - x", ErrInvalidHeader},
+		{"5424 unterminated sd", `<46>1 - relay-01 app - - [ex@1 a="]" x`, ErrInvalidHeader},
+		{"5424 missing sd", "<46>1 - relay-01 app - - x", ErrInvalidHeader},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := ParseMessage([]byte(c.msg), testNow)
+			if !errors.Is(err, c.want) {
+				t.Fatalf("expected %v, got %v", c.want, err)
+			}
+		})
+	}
+}
+
+func TestToLine(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"rfc3164", "<46>Mar  4 11:59:58 relay-01 rsyslogd-pstats: " + pstatsJSON + "\n", "2025-03-04T11:59:58Z relay-01 rsyslogd-pstats: " + pstatsJSON},
+		{"rfc5424", "<46>1 2025-03-04T11:59:58Z relay-01 rsyslogd-pstats - - - " + pstatsJSON, "2025-03-04T11:59:58Z relay-01 rsyslogd-pstats: " + pstatsJSON},
+		{"no header", "2025-03-04T11:59:58Z relay-01 rsyslogd-pstats: " + pstatsJSON + "\r\n", "2025-03-04T11:59:58Z relay-01 rsyslogd-pstats: " + pstatsJSON},
+		{"broken header", "<46>garbage", "<46>garbage"},
+		{"no tag", "<46>Mar  4 11:59:58 relay-01 " + pstatsJSON, "2025-03-04T11:59:58Z relay-01 -: " + pstatsJSON},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			th.AssertEqString(t, c.name, c.want, string(toLine([]byte(c.in), testNow)))
+		})
+	}
+
+	if got := toLine([]byte("\r\n"), testNow); got != nil {
+		t.Errorf("expected nil for empty message, got %q", got)
+	}
+}
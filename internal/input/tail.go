@@ -0,0 +1,326 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"time"
+
+	"github.com/prometheus-community/rsyslog_exporter/internal/fileutil"
+)
+
+// DefaultPollInterval is how often a FileSource checks for new data and
+// rotation when no interval is given.
+const DefaultPollInterval = time.Second
+
+// impstatsFileStamp is the timestamp layout impstats uses for log.file
+// output, followed by ": " and the stats object.
+const impstatsFileStamp = time.ANSIC
+
+// impstatsFileTag is the tag given to lines read from an impstats log file,
+// matching what impstats uses when logging via syslog.
+const impstatsFileTag = "rsyslogd-pstats"
+
+// fileID identifies a file independent of its name, so rotation can be
+// recognised across exporter restarts.
+type fileID struct {
+	Device uint64 `json:"device"`
+	Inode  uint64 `json:"inode"`
+}
+
+// position is the persisted read state of a FileSource.
+type position struct {
+	Path   string `json:"path"`
+	File   fileID `json:"file"`
+	Offset int64  `json:"offset"`
+}
+
+// FileSource follows a file written by impstats' log.file= parameter, like
+// "tail -F". It survives truncation as well as rename/recreate rotation and,
+// when given a position file, resumes where it left off after a restart.
+type FileSource struct {
+	path         string
+	positionFile string
+	pollInterval time.Duration
+	logger       *log.Logger
+}
+
+// NewFileSource returns a Source following the file at path. If positionFile
+// is not empty the read offset is persisted there. A pollInterval of zero
+// selects DefaultPollInterval.
+func NewFileSource(path, positionFile string, pollInterval time.Duration) *FileSource {
+	if pollInterval <= 0 {
+		pollInterval = DefaultPollInterval
+	}
+	return &FileSource{path: path, positionFile: positionFile, pollInterval: pollInterval, logger: log.Default()}
+}
+
+// SetLogger directs the log output of s to logger.
+func (s *FileSource) SetLogger(logger *log.Logger) {
+	s.logger = logger
+}
+
+func (s *FileSource) Run(ctx context.Context, lines chan<- []byte) error {
+	t := &tailer{src: s, buf: make([]byte, 32*1024), saved: -1}
+	defer t.close()
+	s.logger.Printf("Following stats file %s", s.path)
+
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	for {
+		if err := t.poll(ctx, lines); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// tailer holds the state of a running FileSource.
+type tailer struct {
+	src     *FileSource
+	file    *os.File
+	info    os.FileInfo
+	offset  int64
+	saved   int64 // offset last written to the position file
+	polled  bool  // whether poll ran before; only the first open may resume
+	partial []byte
+	buf     []byte
+}
+
+// poll reads everything appended since the last call and handles rotation.
+// Only cancellation is returned as an error; I/O problems are logged and
+// retried on the next poll.
+func (t *tailer) poll(ctx context.Context, lines chan<- []byte) error {
+	first := !t.polled
+	t.polled = true
+	if t.file == nil && !t.open(first) {
+		return nil
+	}
+	if err := t.readAvailable(ctx, lines); err != nil {
+		return err
+	}
+
+	fi, err := os.Stat(t.src.path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		// renamed away but not recreated yet: keep the old file open
+	case err != nil:
+		t.src.logger.Printf("failed to stat %s: %v", t.src.path, err)
+	case !os.SameFile(t.info, fi):
+		// rotated: drain what was written to the old file before the
+		// rename, then continue with the new one from its start.
+		if err := t.readAvailable(ctx, lines); err != nil {
+			return err
+		}
+		if err := t.flushPartial(ctx, lines); err != nil {
+			return err
+		}
+		t.closeFile()
+		if t.open(false) {
+			if err := t.readAvailable(ctx, lines); err != nil {
+				return err
+			}
+		}
+	case fi.Size() < t.offset:
+		t.src.logger.Printf("stats file %s was truncated, reading from the start", t.src.path)
+		t.offset = 0
+		t.partial = t.partial[:0]
+		if _, err := t.file.Seek(0, io.SeekStart); err != nil {
+			t.src.logger.Printf("failed to rewind %s: %v", t.src.path, err)
+			t.closeFile()
+		}
+	}
+	t.savePosition()
+	return nil
+}
+
+// open opens the followed file and positions it. On the first open of a
+// run, a matching saved position is resumed; without one, reading starts at
+// the end of the file like tail does. Files appearing later, including
+// rotated ones, are read from the start. It reports whether the file is open.
+func (t *tailer) open(first bool) bool {
+	f, err := os.Open(t.src.path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.src.logger.Printf("failed to open %s: %v", t.src.path, err)
+		}
+		return false
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		t.src.logger.Printf("failed to stat %s: %v", t.src.path, err)
+		_ = f.Close()
+		return false
+	}
+
+	var start int64
+	if first {
+		start = fi.Size()
+		if pos, ok := t.loadPosition(); ok {
+			start = 0
+			if pos.Path == t.src.path && pos.File == fileIDOf(fi) && pos.Offset <= fi.Size() {
+				start = pos.Offset
+			}
+		}
+	}
+	if _, err := f.Seek(start, io.SeekStart); err != nil {
+		t.src.logger.Printf("failed to seek %s: %v", t.src.path, err)
+		_ = f.Close()
+		return false
+	}
+	t.file, t.info, t.offset = f, fi, start
+	t.partial = t.partial[:0]
+	return true
+}
+
+// readAvailable sends every complete line currently readable from the file.
+// An incomplete last line is kept until its newline arrives.
+func (t *tailer) readAvailable(ctx context.Context, lines chan<- []byte) error {
+	for t.file != nil {
+		n, err := t.file.Read(t.buf)
+		if n > 0 {
+			t.partial = append(t.partial, t.buf[:n]...)
+			if err := t.sendLines(ctx, lines); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF || n == 0 {
+			return nil
+		}
+		if err != nil {
+			t.src.logger.Printf("failed to read %s: %v", t.src.path, err)
+			t.closeFile()
+			return nil
+		}
+	}
+	return nil
+}
+
+// sendLines sends the complete lines buffered in partial and advances the
+// offset past them.
+func (t *tailer) sendLines(ctx context.Context, lines chan<- []byte) error {
+	rest := t.partial
+	for {
+		i := bytes.IndexByte(rest, '\n')
+		if i < 0 {
+			break
+		}
+		if line := fileLine(rest[:i]); line != nil && !send(ctx, lines, line) {
+			return ctx.Err()
+		}
+		t.offset += int64(i + 1)
+		rest = rest[i+1:]
+	}
+	if len(rest) > MaxFrameSize {
+		t.src.logger.Printf("discarding overlong line in %s", t.src.path)
+		t.offset += int64(len(rest))
+		rest = rest[:0]
+	}
+	t.partial = append(t.partial[:0], rest...)
+	return nil
+}
+
+// flushPartial sends a trailing line without newline, as found at the end
+// of a rotated file.
+func (t *tailer) flushPartial(ctx context.Context, lines chan<- []byte) error {
+	if len(t.partial) == 0 {
+		return nil
+	}
+	line := fileLine(t.partial)
+	t.offset += int64(len(t.partial))
+	t.partial = t.partial[:0]
+	if line != nil && !send(ctx, lines, line) {
+		return ctx.Err()
+	}
+	return nil
+}
+
+func (t *tailer) closeFile() {
+	if t.file != nil {
+		_ = t.file.Close()
+		t.file = nil
+	}
+}
+
+func (t *tailer) close() {
+	t.savePosition()
+	t.closeFile()
+}
+
+func (t *tailer) loadPosition() (position, bool) {
+	var pos position
+	if t.src.positionFile == "" {
+		return pos, false
+	}
+	b, err := os.ReadFile(t.src.positionFile)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.src.logger.Printf("failed to read position file %s: %v", t.src.positionFile, err)
+		}
+		return pos, false
+	}
+	if err := json.Unmarshal(b, &pos); err != nil {
+		t.src.logger.Printf("ignoring invalid position file %s: %v", t.src.positionFile, err)
+		return pos, false
+	}
+	return pos, true
+}
+
+// savePosition persists the current offset if it changed since the last
+// save. The file is replaced atomically so a crash never leaves it torn.
+func (t *tailer) savePosition() {
+	if t.src.positionFile == "" || t.file == nil || t.offset == t.saved {
+		return
+	}
+	b, err := json.Marshal(position{Path: t.src.path, File: fileIDOf(t.info), Offset: t.offset})
+	if err != nil {
+		t.src.logger.Printf("failed to encode position: %v", err)
+		return
+	}
+	if err := fileutil.WriteAtomic(t.src.positionFile, b); err != nil {
+		t.src.logger.Printf("failed to write position file: %v", err)
+		return
+	}
+	t.saved = t.offset
+}
+
+// fileLine converts a line of an impstats log file, "Mon Jan _2 15:04:05
+// 2006: {...}", into an exporter line. Other lines, such as syslog formatted
+// ones, are handled like network input.
+func fileLine(b []byte) []byte {
+	b = bytes.TrimRight(b, "\r")
+	if len(b) > len(impstatsFileStamp)+1 && b[len(impstatsFileStamp)] == ':' {
+		stamp := string(b[:len(impstatsFileStamp)])
+		if ts, err := time.ParseInLocation(impstatsFileStamp, stamp, time.Local); err == nil {
+			m := &Message{
+				Timestamp: ts,
+				Tag:       impstatsFileTag,
+				Content:   bytes.TrimLeft(b[len(impstatsFileStamp)+1:], " "),
+			}
+			return m.Line()
+		}
+	}
+	return toLine(b, now())
+}
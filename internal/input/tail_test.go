@@ -0,0 +1,191 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	th "github.com/prometheus-community/rsyslog_exporter/internal/testhelpers"
+)
+
+const testPoll = 5 * time.Millisecond
+
+// startTail runs a FileSource and returns its lines and a stop function.
+func startTail(t *testing.T, src *FileSource) (<-chan []byte, func() error) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	lines := make(chan []byte, 16)
+	errC := make(chan error, 1)
+	go func() { errC <- src.Run(ctx, lines) }()
+	// let the first poll position the file before the test appends to it
+	time.Sleep(5 * testPoll)
+	return lines, func() error {
+		cancel()
+		return <-errC
+	}
+}
+
+func appendFile(t *testing.T, path, data string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+	if _, err := f.WriteString(data); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+}
+
+func expectNoLine(t *testing.T, lines <-chan []byte) {
+	t.Helper()
+	select {
+	case l := <-lines:
+		t.Fatalf("unexpected line %q", l)
+	case <-time.After(10 * testPoll):
+	}
+}
+
+func TestFileSourceStartsAtEnd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "impstats.log")
+	appendFile(t, path, "old\n")
+
+	lines, stop := startTail(t, NewFileSource(path, "", testPoll))
+	appendFile(t, path, "new")
+	expectNoLine(t, lines) // incomplete until the newline arrives
+	appendFile(t, path, " line\n")
+	expectLine(t, lines, "new line")
+
+	if err := stop(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestFileSourceWaitsForFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "impstats.log")
+	lines, stop := startTail(t, NewFileSource(path, "", testPoll))
+	defer func() { _ = stop() }()
+
+	appendFile(t, path, "first\n")
+	expectLine(t, lines, "first")
+}
+
+func TestFileSourceRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "impstats.log")
+	appendFile(t, path, "")
+	lines, stop := startTail(t, NewFileSource(path, "", testPoll))
+	defer func() { _ = stop() }()
+
+	appendFile(t, path, "before\n")
+	expectLine(t, lines, "before")
+
+	// rename/recreate rotation, with a last line written to the old file
+	// after the rename and without trailing newline
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatalf("rename failed: %v", err)
+	}
+	appendFile(t, path+".1", "late")
+	appendFile(t, path, "after\n")
+	expectLine(t, lines, "late")
+	expectLine(t, lines, "after")
+
+	// copytruncate rotation
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatalf("truncate failed: %v", err)
+	}
+	time.Sleep(5 * testPoll)
+	appendFile(t, path, "x\n")
+	expectLine(t, lines, "x")
+}
+
+func TestFileSourceResumesFromPosition(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "impstats.log")
+	posFile := filepath.Join(dir, "impstats.pos")
+	appendFile(t, path, "")
+
+	lines, stop := startTail(t, NewFileSource(path, posFile, testPoll))
+	appendFile(t, path, "one\n")
+	expectLine(t, lines, "one")
+	_ = stop()
+
+	// written while the exporter was down
+	appendFile(t, path, "two\n")
+
+	lines, stop = startTail(t, NewFileSource(path, posFile, testPoll))
+	defer func() { _ = stop() }()
+	expectLine(t, lines, "two")
+}
+
+func TestFileSourceRotatedWhileDown(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "impstats.log")
+	posFile := filepath.Join(dir, "impstats.pos")
+	appendFile(t, path, "")
+
+	lines, stop := startTail(t, NewFileSource(path, posFile, testPoll))
+	appendFile(t, path, "one\n")
+	expectLine(t, lines, "one")
+	_ = stop()
+
+	// keep the old file around so the new one cannot reuse its inode
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatalf("rename failed: %v", err)
+	}
+	appendFile(t, path, "fresh\n")
+
+	lines, stop = startTail(t, NewFileSource(path, posFile, testPoll))
+	defer func() { _ = stop() }()
+	expectLine(t, lines, "fresh")
+}
+
+func TestFileSourceIgnoresInvalidPosition(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "impstats.log")
+	posFile := filepath.Join(dir, "impstats.pos")
+	appendFile(t, path, "old\n")
+	if err := os.WriteFile(posFile, []byte("{"), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	lines, stop := startTail(t, NewFileSource(path, posFile, testPoll))
+	defer func() { _ = stop() }()
+	appendFile(t, path, "new\n")
+	expectLine(t, lines, "new")
+}
+
+func TestNewFileSourceDefaultPoll(t *testing.T) {
+	if got := NewFileSource("x", "", 0).pollInterval; got != DefaultPollInterval {
+		t.Fatalf("wanted %v, got %v", DefaultPollInterval, got)
+	}
+}
+
+func TestFileLine(t *testing.T) {
+	origNow := now
+	defer func() { now = origNow }()
+	now = func() time.Time { return testNow }
+
+	stamp := time.Date(2025, time.March, 4, 11, 59, 58, 0, time.Local)
+	got := fileLine([]byte("Tue Mar  4 11:59:58 2025: " + pstatsJSON + "\r"))
+	want := stamp.Format(time.RFC3339Nano) + " - rsyslogd-pstats: " + pstatsJSON
+	th.AssertEqString(t, "impstats file line", want, string(got))
+
+	th.AssertEqString(t, "syslog line", renderedLine, string(fileLine([]byte(rfc3164Message))))
+}
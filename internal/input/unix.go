@@ -0,0 +1,128 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net"
+	"os"
+)
+
+// Unix socket types accepted by NewUnixSource.
+const (
+	UnixDatagram = "datagram"
+	UnixStream   = "stream"
+)
+
+// UnixSource receives syslog messages on a Unix domain socket, as sent by
+// rsyslog's omuxsock. Datagram sockets carry one message per datagram,
+// stream sockets use the same framing as TCPSource.
+type UnixSource struct {
+	path   string
+	stream bool
+	logger *log.Logger
+	// onListen is called with the bound address once listening; test hook.
+	onListen func(net.Addr)
+}
+
+// NewUnixSource returns a Source listening on the Unix socket at path.
+// socketType is either UnixDatagram or UnixStream.
+func NewUnixSource(path, socketType string) (*UnixSource, error) {
+	switch socketType {
+	case UnixDatagram:
+		return &UnixSource{path: path, logger: log.Default()}, nil
+	case UnixStream:
+		return &UnixSource{path: path, stream: true, logger: log.Default()}, nil
+	}
+	return nil, fmt.Errorf("unknown unix socket type %q, expected %q or %q", socketType, UnixDatagram, UnixStream)
+}
+
+// SetLogger directs the log output of s to logger.
+func (s *UnixSource) SetLogger(logger *log.Logger) {
+	s.logger = logger
+}
+
+func (s *UnixSource) Run(ctx context.Context, lines chan<- []byte) error {
+	if err := removeStaleSocket(s.path); err != nil {
+		return err
+	}
+	if s.stream {
+		return s.runStream(ctx, lines)
+	}
+	return s.runDatagram(ctx, lines)
+}
+
+func (s *UnixSource) runDatagram(ctx context.Context, lines chan<- []byte) error {
+	var lc net.ListenConfig
+	conn, err := lc.ListenPacket(ctx, "unixgram", s.path)
+	if err != nil {
+		return fmt.Errorf("failed to listen on unix datagram socket %s: %w", s.path, err)
+	}
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer func() {
+		stop()
+		_ = conn.Close()
+		// unlike stream listeners, datagram sockets are not unlinked on close
+		_ = os.Remove(s.path)
+	}()
+	if s.onListen != nil {
+		s.onListen(conn.LocalAddr())
+	}
+	s.logger.Printf("Receiving stats on unix datagram socket %s", s.path)
+
+	return packetLoop(ctx, conn, lines)
+}
+
+func (s *UnixSource) runStream(ctx context.Context, lines chan<- []byte) error {
+	var lc net.ListenConfig
+	ln, err := lc.Listen(ctx, "unix", s.path)
+	if err != nil {
+		return fmt.Errorf("failed to listen on unix stream socket %s: %w", s.path, err)
+	}
+	stop := context.AfterFunc(ctx, func() { _ = ln.Close() })
+	defer func() {
+		stop()
+		_ = ln.Close()
+	}()
+	if s.onListen != nil {
+		s.onListen(ln.Addr())
+	}
+	s.logger.Printf("Receiving stats on unix stream socket %s", s.path)
+
+	return acceptLoop(ctx, ln, lines, s.logger)
+}
+
+// removeStaleSocket deletes a socket file left behind by a previous run so
+// that binding does not fail with "address already in use". Anything other
+// than a socket is left alone.
+func removeStaleSocket(path string) error {
+	fi, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stat unix socket %s: %w", path, err)
+	}
+	if fi.Mode()&fs.ModeSocket == 0 {
+		return fmt.Errorf("refusing to replace %s: not a socket", path)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove stale unix socket %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewUnixSourceInvalidType(t *testing.T) {
+	if _, err := NewUnixSource("/tmp/x.sock", "seqpacket"); err == nil {
+		t.Fatalf("expected error for unknown socket type")
+	}
+}
+
+func TestUnixSource(t *testing.T) {
+	cases := []struct {
+		socketType string
+		network    string
+	}{
+		{UnixDatagram, "unixgram"},
+		{UnixStream, "unix"},
+	}
+	for _, c := range cases {
+		t.Run(c.socketType, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "stats.sock")
+			// a stale socket from a previous run must not prevent binding
+			stale, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+			if err != nil {
+				t.Fatalf("failed to create stale socket: %v", err)
+			}
+			_ = stale.Close()
+
+			src, err := NewUnixSource(path, c.socketType)
+			if err != nil {
+				t.Fatalf("NewUnixSource failed: %v", err)
+			}
+			bound := make(chan net.Addr, 1)
+			src.onListen = func(a net.Addr) { bound <- a }
+			lines, _, stop := runSource(t, src, bound)
+
+			conn, err := net.Dial(c.network, path)
+			if err != nil {
+				t.Fatalf("dial failed: %v", err)
+			}
+			defer func() { _ = conn.Close() }()
+			if _, err := conn.Write([]byte(rfc3164Message + "\n")); err != nil {
+				t.Fatalf("write failed: %v", err)
+			}
+			expectLine(t, lines, renderedLine)
+
+			if err := stop(); !errors.Is(err, context.Canceled) {
+				t.Fatalf("expected context.Canceled after stop, got %v", err)
+			}
+			if _, err := os.Lstat(path); !os.IsNotExist(err) {
+				t.Fatalf("expected socket to be removed on shutdown, got %v", err)
+			}
+		})
+	}
+}
+
+func TestUnixSourceRefusesRegularFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "not-a-socket")
+	if err := os.WriteFile(path, []byte("keep me"), 0o600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	src, err := NewUnixSource(path, UnixDatagram)
+	if err != nil {
+		t.Fatalf("NewUnixSource failed: %v", err)
+	}
+	if err := src.Run(context.Background(), make(chan []byte)); err == nil {
+		t.Fatalf("expected error when path is a regular file")
+	}
+	if b, err := os.ReadFile(path); err != nil || string(b) != "keep me" {
+		t.Fatalf("regular file must be left untouched: %q, %v", b, err)
+	}
+}
+
+func TestUnixSourceListenErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing-dir", "stats.sock")
+	for _, socketType := range []string{UnixDatagram, UnixStream} {
+		src, err := NewUnixSource(path, socketType)
+		if err != nil {
+			t.Fatalf("NewUnixSource failed: %v", err)
+		}
+		if err := src.Run(context.Background(), make(chan []byte)); err == nil {
+			t.Errorf("%s: expected listen error for missing directory", socketType)
+		}
+	}
+}
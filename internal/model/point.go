@@ -14,7 +14,8 @@
 package model
 
 import (
-	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -24,26 +25,50 @@ type PointType int
 const (
 	Counter PointType = iota
 	Gauge
+	// Info points carry metadata in their labels. They are exported as
+	// gauges of value 1, to be joined onto other series.
+	Info
 )
 
+// HostLabelName is the label carrying Point.Host.
+const HostLabelName = "host"
+
+// OriginLabelName is the label carrying the origin of the impstats object
+// a point was decoded from, e.g. "core.queue".
+const OriginLabelName = "origin"
+
+// Label is a name/value pair of a Point.
+type Label struct {
+	Name  string
+	Value string
+}
+
 type Point struct {
 	Name        string
 	Description string
 	Type        PointType
-	Value       int64
-	LabelName   string
-	LabelValue  string
+	Value       float64
+	// Labels are the variable labels of the point, exported in order.
+	Labels []Label
+	// Host is the rsyslog instance that reported the point. It is only set
+	// when stats of several hosts are aggregated and then exported as the
+	// "host" label.
+	Host string
+	// ObjectType is the kind of impstats object the point was decoded
+	// from, e.g. "queue". It is empty for the exporter's own points.
+	ObjectType string
+}
+
+// PromName returns the exported metric name of the point, see SanitizeName.
+func (p *Point) PromName() string {
+	return prometheus.BuildFQName("", "rsyslog", SanitizeName(p.Name))
 }
 
 func (p *Point) PromDescription() *prometheus.Desc {
-	var variableLabels []string
-	if p.PromLabelName() != "" {
-		variableLabels = []string{p.PromLabelName()}
-	}
 	return prometheus.NewDesc(
-		prometheus.BuildFQName("", "rsyslog", p.Name),
+		p.PromName(),
 		p.Description,
-		variableLabels,
+		p.PromLabelNames(),
 		nil,
 	)
 }
@@ -56,20 +81,86 @@ func (p *Point) PromType() prometheus.ValueType {
 }
 
 func (p *Point) PromValue() float64 {
-	return float64(p.Value)
+	if p.Type == Info {
+		return 1
+	}
+	return p.Value
+}
+
+// Label returns the value of the label called name, or "" if the point has
+// no such label.
+func (p *Point) Label(name string) string {
+	for _, l := range p.Labels {
+		if l.Name == name {
+			return l.Value
+		}
+	}
+	return ""
 }
 
-func (p *Point) PromLabelValue() string {
-	return p.LabelValue
+// HasLabel reports whether the point has a label called name.
+func (p *Point) HasLabel(name string) bool {
+	for _, l := range p.Labels {
+		if l.Name == name {
+			return true
+		}
+	}
+	return false
 }
 
-func (p *Point) PromLabelName() string {
-	return p.LabelName
+// PromLabelNames returns the sanitized variable label names of the point, in
+// the order of PromLabelValues.
+func (p *Point) PromLabelNames() []string {
+	names := make([]string, 0, len(p.Labels)+1)
+	for _, l := range p.Labels {
+		names = append(names, SanitizeLabelName(l.Name))
+	}
+	if p.Host != "" {
+		names = append(names, HostLabelName)
+	}
+	return names
 }
 
+// PromLabelValues returns the sanitized variable label values of the point.
+func (p *Point) PromLabelValues() []string {
+	values := make([]string, 0, len(p.Labels)+1)
+	for _, l := range p.Labels {
+		values = append(values, SanitizeLabelValue(l.Value))
+	}
+	if p.Host != "" {
+		values = append(values, SanitizeLabelValue(p.Host))
+	}
+	return values
+}
+
+// Key identifies the series of the point in a Store. It is the name
+// followed by the quoted label values, e.g. `queue_size{queue="main Q"}`, so
+// that distinct label sets never share a key.
 func (p *Point) Key() string {
-	if p.LabelValue == "" {
+	if len(p.Labels) == 0 && p.Host == "" {
 		return p.Name
 	}
-	return fmt.Sprintf("%s.%s", p.Name, p.LabelValue)
+	var b strings.Builder
+	b.WriteString(p.Name)
+	b.WriteByte('{')
+	for i, l := range p.Labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		writeKeyLabel(&b, l.Name, l.Value)
+	}
+	if p.Host != "" {
+		if len(p.Labels) > 0 {
+			b.WriteByte(',')
+		}
+		writeKeyLabel(&b, HostLabelName, p.Host)
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func writeKeyLabel(b *strings.Builder, name, value string) {
+	b.WriteString(name)
+	b.WriteByte('=')
+	b.WriteString(strconv.Quote(value))
 }
@@ -26,7 +26,7 @@ func TestCounter(t *testing.T) {
 	p1 := &Point{
 		Name:  "my_counter",
 		Type:  Counter,
-		Value: int64(10),
+		Value: 10,
 	}
 
 	if want, got := float64(10), p1.PromValue(); want != got {
@@ -48,7 +48,7 @@ func TestGauge(t *testing.T) {
 	p1 := &Point{
 		Name:  "my_gauge",
 		Type:  Gauge,
-		Value: int64(10),
+		Value: 10,
 	}
 
 	if want, got := float64(10), p1.PromValue(); want != got {
@@ -66,38 +66,109 @@ func TestGauge(t *testing.T) {
 
 }
 
-func TestPromLabelValueAndKey(t *testing.T) {
+func TestLabelsAndKey(t *testing.T) {
 	p := &Point{
-		Name:       "foo",
-		Type:       Gauge,
-		Value:      7,
-		LabelName:  "lbl",
-		LabelValue: "v1",
+		Name:   "foo",
+		Type:   Gauge,
+		Value:  7,
+		Labels: []Label{{Name: "lbl", Value: "v1"}},
 	}
 
-	if want, got := "v1", p.PromLabelValue(); want != got {
+	if want, got := "v1", p.Label("lbl"); want != got {
 		t.Errorf(wantGotFmt, want, got)
 	}
 
-	if want, got := "lbl", p.PromLabelName(); want != got {
+	if want, got := "", p.Label("missing"); want != got {
 		t.Errorf(wantGotFmt, want, got)
 	}
 
-	if want, got := "foo.v1", p.Key(); want != got {
+	if want, got := `foo{lbl="v1"}`, p.Key(); want != got {
 		t.Errorf(wantGotFmt, want, got)
 	}
 
-	// when LabelValue is empty, Key should be just the name
-	p.LabelValue = ""
+	// without labels, Key should be just the name
+	p.Labels = nil
 	if want, got := "foo", p.Key(); want != got {
 		t.Errorf(wantGotFmt, want, got)
 	}
 }
 
+func TestMultipleLabels(t *testing.T) {
+	p := &Point{Name: "foo", Labels: []Label{{Name: "action", Value: "fwd"}, {Name: "worker", Value: "w0"}}}
+	if want, got := "action,worker", strings.Join(p.PromLabelNames(), ","); want != got {
+		t.Errorf(wantGotFmt, want, got)
+	}
+	if want, got := "fwd,w0", strings.Join(p.PromLabelValues(), ","); want != got {
+		t.Errorf(wantGotFmt, want, got)
+	}
+	if want, got := `foo{action="fwd",worker="w0"}`, p.Key(); want != got {
+		t.Errorf(wantGotFmt, want, got)
+	}
+	if d, want := p.PromDescription().String(), "variableLabels: {action,worker}"; !strings.Contains(d, want) {
+		t.Fatalf("expected %q in description: %s", want, d)
+	}
+}
+
+func TestKeyDoesNotCollide(t *testing.T) {
+	// a dotted label value used to share its key with a longer metric name
+	dotted := &Point{Name: "foo", Labels: []Label{{Name: "lbl", Value: "bar.baz"}}}
+	longer := &Point{Name: "foo.bar", Labels: []Label{{Name: "lbl", Value: "baz"}}}
+	if dotted.Key() == longer.Key() {
+		t.Errorf("expected distinct keys, both got %q", dotted.Key())
+	}
+
+	// label values containing separators and quotes stay apart
+	a := &Point{Name: "foo", Labels: []Label{{Name: "a", Value: `x",b="y`}}}
+	b := &Point{Name: "foo", Labels: []Label{{Name: "a", Value: "x"}, {Name: "b", Value: "y"}}}
+	if a.Key() == b.Key() {
+		t.Errorf("expected distinct keys, both got %q", a.Key())
+	}
+}
+
 func TestPromDescriptionWithLabel(t *testing.T) {
-	p := &Point{Name: "foo", Description: "bar", LabelName: "lbl", LabelValue: "v"}
+	p := &Point{Name: "foo", Description: "bar", Labels: []Label{{Name: "lbl", Value: "v"}}}
 	d := p.PromDescription().String()
 	if want := "variableLabels: {lbl}"; !strings.Contains(d, want) {
 		t.Fatalf("expected %q in description: %s", want, d)
 	}
 }
+
+func TestHostLabel(t *testing.T) {
+	p := &Point{Name: "foo", Labels: []Label{{Name: "lbl", Value: "v"}}, Host: "relay-03"}
+	if want, got := `foo{lbl="v",host="relay-03"}`, p.Key(); want != got {
+		t.Errorf(wantGotFmt, want, got)
+	}
+	if want, got := "lbl,host", strings.Join(p.PromLabelNames(), ","); want != got {
+		t.Errorf(wantGotFmt, want, got)
+	}
+	if want, got := "v,relay-03", strings.Join(p.PromLabelValues(), ","); want != got {
+		t.Errorf(wantGotFmt, want, got)
+	}
+	if d, want := p.PromDescription().String(), "variableLabels: {lbl,host}"; !strings.Contains(d, want) {
+		t.Fatalf("expected %q in description: %s", want, d)
+	}
+
+	// series of the same object on another host are kept apart
+	other := *p
+	other.Host = "relay-04"
+	if p.Key() == other.Key() {
+		t.Errorf("expected distinct keys per host, got %q", p.Key())
+	}
+}
+
+func TestHasLabel(t *testing.T) {
+	p := &Point{Name: "p", Labels: []Label{{Name: OriginLabelName, Value: ""}}}
+	if !p.HasLabel(OriginLabelName) || p.HasLabel("queue") {
+		t.Errorf("unexpected labels %v", p.Labels)
+	}
+}
+
+func TestInfo(t *testing.T) {
+	p := &Point{Name: "object_info", Type: Info}
+	if want, got := float64(1), p.PromValue(); want != got {
+		t.Errorf("want '%f', got '%f'", want, got)
+	}
+	if want, got := prometheus.GaugeValue, p.PromType(); want != got {
+		t.Errorf("want '%v', got '%v'", want, got)
+	}
+}
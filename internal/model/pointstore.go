@@ -17,6 +17,7 @@ import (
 	"errors"
 	"sort"
 	"sync"
+	"time"
 )
 
 var (
@@ -25,12 +26,17 @@ var (
 
 type Store struct {
 	pointMap map[string]*Point
-	lock     *sync.RWMutex
+	// updated holds when each point was last set.
+	updated map[string]time.Time
+	// gen counts the changes of the store, see Generation.
+	gen  uint64
+	lock *sync.RWMutex
 }
 
 func NewStore() *Store {
 	return &Store{
 		pointMap: make(map[string]*Point),
+		updated:  make(map[string]time.Time),
 		lock:     &sync.RWMutex{},
 	}
 }
@@ -51,20 +57,106 @@ func (ps *Store) Keys() []string {
 }
 
 func (ps *Store) Set(p *Point) error {
+	return ps.SetAt(p, time.Now())
+}
+
+// SetAt stores p as updated at the given time.
+func (ps *Store) SetAt(p *Point, at time.Time) error {
 	var err error
+	key := p.Key()
 	ps.lock.Lock()
-	ps.pointMap[p.Key()] = p
+	ps.pointMap[key] = p
+	ps.updated[key] = at
+	ps.gen++
 	ps.lock.Unlock()
 	return err
 }
 
+// SetBatch stores all points as updated at the given time in one step, so
+// that readers see either none or all of them.
+func (ps *Store) SetBatch(points []*Point, at time.Time) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	for _, p := range points {
+		key := p.Key()
+		ps.pointMap[key] = p
+		ps.updated[key] = at
+	}
+	ps.gen++
+}
+
+// Generation returns a number that changes whenever points are set or
+// removed, so that readers can tell whether a previous Snapshot is still
+// current.
+func (ps *Store) Generation() uint64 {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+	return ps.gen
+}
+
+// Snapshot returns all points ordered by key, as of a single point in time.
+func (ps *Store) Snapshot() []*Point {
+	ps.lock.RLock()
+	keys := make([]string, 0, len(ps.pointMap))
+	for k := range ps.pointMap {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	points := make([]*Point, len(keys))
+	for i, k := range keys {
+		points[i] = ps.pointMap[k]
+	}
+	ps.lock.RUnlock()
+	return points
+}
+
+// Entry is a point together with the time it was last set.
+type Entry struct {
+	Point   *Point
+	Updated time.Time
+}
+
+// Entries returns all points with the time they were last set, ordered by
+// key.
+func (ps *Store) Entries() []Entry {
+	ps.lock.RLock()
+	entries := make([]Entry, 0, len(ps.pointMap))
+	for k, p := range ps.pointMap {
+		entries = append(entries, Entry{Point: p, Updated: ps.updated[k]})
+	}
+	ps.lock.RUnlock()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Point.Key() < entries[j].Point.Key() })
+	return entries
+}
+
 // Delete removes a point by key; used in tests to simulate concurrent mutation during Describe.
 func (ps *Store) Delete(name string) {
 	ps.lock.Lock()
 	delete(ps.pointMap, name)
+	delete(ps.updated, name)
+	ps.gen++
 	ps.lock.Unlock()
 }
 
+// Expire removes every point for which stale returns true, given the time
+// the point was last set, and returns the number of removed points.
+func (ps *Store) Expire(stale func(p *Point, updated time.Time) bool) int {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	removed := 0
+	for key, p := range ps.pointMap {
+		if stale(p, ps.updated[key]) {
+			delete(ps.pointMap, key)
+			delete(ps.updated, key)
+			removed++
+		}
+	}
+	if removed > 0 {
+		ps.gen++
+	}
+	return removed
+}
+
 func (ps *Store) Get(name string) (*Point, error) {
 	ps.lock.RLock()
 	defer ps.lock.RUnlock()
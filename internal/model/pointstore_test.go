@@ -15,6 +15,7 @@ package model
 
 import (
 	"testing"
+	"time"
 
 	th "github.com/prometheus-community/rsyslog_exporter/internal/testhelpers"
 )
@@ -25,13 +26,13 @@ func TestPointStore(t *testing.T) {
 	s1 := &Point{
 		Name:  "my counter",
 		Type:  Counter,
-		Value: int64(10),
+		Value: float64(10),
 	}
 
 	s2 := &Point{
 		Name:  "my counter",
 		Type:  Counter,
-		Value: int64(5),
+		Value: float64(5),
 	}
 
 	err := ps.Set(s1)
@@ -44,8 +45,8 @@ func TestPointStore(t *testing.T) {
 		t.Error(err)
 	}
 
-	if want, got := int64(10), got.Value; want != got {
-		t.Errorf(th.ExpectedActualIntFmt, want, got)
+	if want, got := float64(10), got.Value; want != got {
+		t.Errorf(th.ExpectedActualFloatFmt, want, got)
 	}
 
 	err = ps.Set(s2)
@@ -58,14 +59,14 @@ func TestPointStore(t *testing.T) {
 		t.Error(err)
 	}
 
-	if want, got := int64(5), got.Value; want != got {
-		t.Errorf(th.ExpectedActualIntFmt, want, got)
+	if want, got := float64(5), got.Value; want != got {
+		t.Errorf(th.ExpectedActualFloatFmt, want, got)
 	}
 
 	s3 := &Point{
 		Name:  "my gauge",
 		Type:  Gauge,
-		Value: int64(20),
+		Value: float64(20),
 	}
 
 	err = ps.Set(s3)
@@ -78,14 +79,14 @@ func TestPointStore(t *testing.T) {
 		t.Error(err)
 	}
 
-	if want, got := int64(20), got.Value; want != got {
-		t.Errorf(th.ExpectedActualIntFmt, want, got)
+	if want, got := float64(20), got.Value; want != got {
+		t.Errorf(th.ExpectedActualFloatFmt, want, got)
 	}
 
 	s4 := &Point{
 		Name:  "my gauge",
 		Type:  Gauge,
-		Value: int64(15),
+		Value: float64(15),
 	}
 
 	err = ps.Set(s4)
@@ -98,8 +99,8 @@ func TestPointStore(t *testing.T) {
 		t.Error(err)
 	}
 
-	if want, got := int64(15), got.Value; want != got {
-		t.Errorf(th.ExpectedActualIntFmt, want, got)
+	if want, got := float64(15), got.Value; want != got {
+		t.Errorf(th.ExpectedActualFloatFmt, want, got)
 	}
 
 	_, err = ps.Get("no point")
@@ -143,3 +144,97 @@ func TestDeleteRemovesKey(t *testing.T) {
 		t.Fatalf("expected ErrPointNotFound after delete, got %v", err)
 	}
 }
+
+func TestExpire(t *testing.T) {
+	ps := NewStore()
+	start := time.Date(2025, time.March, 4, 12, 0, 0, 0, time.UTC)
+	old := &Point{Name: "old", Type: Gauge}
+	fresh := &Point{Name: "fresh", Type: Gauge}
+	_ = ps.SetAt(old, start)
+	_ = ps.SetAt(fresh, start.Add(time.Minute))
+
+	cutoff := start.Add(30 * time.Second)
+	removed := ps.Expire(func(_ *Point, updated time.Time) bool { return updated.Before(cutoff) })
+	if want, got := 1, removed; want != got {
+		t.Fatalf(th.ExpectedActualIntFmt, want, got)
+	}
+	if _, err := ps.Get(old.Key()); err != ErrPointNotFound {
+		t.Fatalf("expected expired point to be removed, got %v", err)
+	}
+	if _, err := ps.Get(fresh.Key()); err != nil {
+		t.Fatalf("expected fresh point to be kept: %v", err)
+	}
+
+	// setting a point again renews it
+	_ = ps.SetAt(fresh, start.Add(2*time.Minute))
+	cutoff = start.Add(90 * time.Second)
+	if removed := ps.Expire(func(_ *Point, updated time.Time) bool { return updated.Before(cutoff) }); removed != 0 {
+		t.Fatalf("expected renewed point to be kept, removed %d", removed)
+	}
+}
+
+func TestSetBatchAndSnapshot(t *testing.T) {
+	ps := NewStore()
+	_ = ps.Set(&Point{Name: "c", Type: Gauge, Value: 1})
+	ps.SetBatch([]*Point{
+		{Name: "b", Type: Gauge, Value: 2},
+		{Name: "a", Type: Gauge, Value: 3},
+	}, time.Now())
+
+	snap := ps.Snapshot()
+	if len(snap) != 3 {
+		t.Fatalf("expected 3 points, got %d", len(snap))
+	}
+	for i, want := range []string{"a", "b", "c"} {
+		th.AssertEqString(t, "snapshot order", want, snap[i].Key())
+	}
+
+	// later updates do not change a taken snapshot
+	_ = ps.Set(&Point{Name: "a", Type: Gauge, Value: 4})
+	if want, got := float64(3), snap[0].Value; want != got {
+		t.Errorf(th.ExpectedActualFloatFmt, want, got)
+	}
+}
+
+func TestEntries(t *testing.T) {
+	ps := NewStore()
+	at := time.Date(2025, time.March, 4, 12, 0, 0, 0, time.UTC)
+	_ = ps.SetAt(&Point{Name: "b", Type: Gauge}, at)
+	_ = ps.SetAt(&Point{Name: "a", Type: Gauge}, at.Add(time.Minute))
+
+	entries := ps.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	th.AssertEqString(t, "first key", "a", entries[0].Point.Key())
+	if !entries[0].Updated.Equal(at.Add(time.Minute)) || !entries[1].Updated.Equal(at) {
+		t.Fatalf("unexpected update times %v, %v", entries[0].Updated, entries[1].Updated)
+	}
+}
+
+func TestStoreGeneration(t *testing.T) {
+	ps := NewStore()
+	gen := ps.Generation()
+	p := &Point{Name: "p", Type: Gauge, Value: 1}
+	steps := []struct {
+		name   string
+		change func()
+	}{
+		{"Set", func() { _ = ps.Set(p) }},
+		{"SetBatch", func() { ps.SetBatch([]*Point{p}, time.Now()) }},
+		{"Expire", func() { ps.Expire(func(*Point, time.Time) bool { return true }) }},
+	}
+	for _, step := range steps {
+		step.change()
+		if next := ps.Generation(); next == gen {
+			t.Errorf("%s: expected a new generation", step.name)
+		} else {
+			gen = next
+		}
+	}
+	// removing nothing is no change
+	ps.Expire(func(*Point, time.Time) bool { return true })
+	if ps.Generation() != gen {
+		t.Errorf("expected the generation to stay when nothing expired")
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// SanitizeName returns s with every character that is not allowed in a
+// classic Prometheus metric or label name, i.e. anything but ASCII letters,
+// digits and underscores, replaced by an underscore. Names taken from the
+// rsyslog configuration, such as dynstats buckets, may contain any text.
+func SanitizeName(s string) string {
+	if validName(s) {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if nameChar(r) {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// validName reports whether s is made of name characters only.
+func validName(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !nameChar(rune(s[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+func nameChar(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+// SanitizeLabelName returns the label name s as SanitizeName does, and
+// prefixed with an underscore if it would start with a digit.
+func SanitizeLabelName(s string) string {
+	s = SanitizeName(s)
+	if s != "" && s[0] >= '0' && s[0] <= '9' {
+		return "_" + s
+	}
+	return s
+}
+
+// SanitizeLabelValue returns s with invalid UTF-8 replaced by U+FFFD, as
+// label values must be valid UTF-8.
+func SanitizeLabelValue(s string) string {
+	if utf8.ValidString(s) {
+		return s
+	}
+	return strings.ToValidUTF8(s, "\uFFFD")
+}
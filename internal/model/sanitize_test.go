@@ -0,0 +1,51 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import (
+	"fmt"
+	"testing"
+
+	th "github.com/prometheus-community/rsyslog_exporter/internal/testhelpers"
+)
+
+func TestSanitizeName(t *testing.T) {
+	cases := map[string]string{
+		"queue_size":           "queue_size",
+		"dynstat_msg-per host": "dynstat_msg_per_host",
+		"dynstat_a.b:c":        "dynstat_a_b_c",
+		"dynstat_grüße":        "dynstat_gr__e",
+		"dynstat_\xffinvalid":  "dynstat__invalid",
+		"":                     "",
+	}
+	for in, want := range cases {
+		th.AssertEqString(t, in, want, SanitizeName(in))
+	}
+	th.AssertEqString(t, "label", "_1st", SanitizeLabelName("1st"))
+	th.AssertEqString(t, "label", "ok", SanitizeLabelName("ok"))
+}
+
+func TestSanitizeLabelValue(t *testing.T) {
+	th.AssertEqString(t, "valid", "main Q ü", SanitizeLabelValue("main Q ü"))
+	th.AssertEqString(t, "invalid", "bad�name", SanitizeLabelValue("bad\xff\xfename"))
+}
+
+func TestPromSanitized(t *testing.T) {
+	p := &Point{Name: "dynstat_a-b", Labels: []Label{{Name: "counter-name", Value: "x\xff"}}, Host: "h"}
+	th.AssertEqString(t, "name", "rsyslog_dynstat_a_b", p.PromName())
+	th.AssertEqString(t, "label names", "[counter_name host]", fmt.Sprint(p.PromLabelNames()))
+	th.AssertEqString(t, "label values", "[x� h]", fmt.Sprint(p.PromLabelValues()))
+	// the store keeps the series apart by the raw name
+	th.AssertEqString(t, "key", `dynstat_a-b{counter-name="x\xff",host="h"}`, p.Key())
+}
@@ -14,7 +14,6 @@
 package rsyslog
 
 import (
-	"encoding/json"
 	"fmt"
 
 	"github.com/prometheus-community/rsyslog_exporter/internal/model"
@@ -23,69 +22,87 @@ import (
 // Action represents rsyslog action statistics.
 type Action struct {
 	Name              string `json:"name"`
-	Processed         int64  `json:"processed"`
-	Failed            int64  `json:"failed"`
-	Suspended         int64  `json:"suspended"`
-	SuspendedDuration int64  `json:"suspended.duration"`
-	Resumed           int64  `json:"resumed"`
+	Processed         Number `json:"processed"`
+	Failed            Number `json:"failed"`
+	Suspended         Number `json:"suspended"`
+	SuspendedDuration Number `json:"suspended.duration"`
+	Resumed           Number `json:"resumed"`
 }
 
 func NewActionFromJSON(b []byte) (*Action, error) {
-	var pstat Action
-	err := json.Unmarshal(b, &pstat)
-	if err != nil {
+	var f Fields
+	if err := f.Parse(b); err != nil {
 		return nil, fmt.Errorf("failed to decode action stat `%v`: %w", string(b), err)
 	}
-	return &pstat, nil
+	return NewActionFromFields(&f), nil
+}
+
+// NewActionFromFields returns the action stats of the parsed object f.
+func NewActionFromFields(f *Fields) *Action {
+	return &Action{
+		Name:              f.String("name"),
+		Processed:         f.Number("processed"),
+		Failed:            f.Number("failed"),
+		Suspended:         f.Number("suspended"),
+		SuspendedDuration: f.Number("suspended.duration"),
+		Resumed:           f.Number("resumed"),
+	}
 }
 
 func (a *Action) ToPoints() []*model.Point {
 	points := make([]*model.Point, 5)
+	labels := a.labels()
 
 	points[0] = &model.Point{
 		Name:        "action_processed",
 		Type:        model.Counter,
-		Value:       a.Processed,
+		Value:       float64(a.Processed),
 		Description: "messages processed",
-		LabelName:   "action",
-		LabelValue:  a.Name,
+		Labels:      labels,
 	}
 
 	points[1] = &model.Point{
 		Name:        "action_failed",
 		Type:        model.Counter,
-		Value:       a.Failed,
+		Value:       float64(a.Failed),
 		Description: "messages failed",
-		LabelName:   "action",
-		LabelValue:  a.Name,
+		Labels:      labels,
 	}
 
 	points[2] = &model.Point{
 		Name:        "action_suspended",
 		Type:        model.Counter,
-		Value:       a.Suspended,
+		Value:       float64(a.Suspended),
 		Description: "times suspended",
-		LabelName:   "action",
-		LabelValue:  a.Name,
+		Labels:      labels,
 	}
 
 	points[3] = &model.Point{
 		Name:        "action_suspended_duration",
 		Type:        model.Counter,
-		Value:       a.SuspendedDuration,
+		Value:       float64(a.SuspendedDuration),
 		Description: "time spent suspended",
-		LabelName:   "action",
-		LabelValue:  a.Name,
+		Labels:      labels,
 	}
 
 	points[4] = &model.Point{
 		Name:        "action_resumed",
 		Type:        model.Counter,
-		Value:       a.Resumed,
+		Value:       float64(a.Resumed),
 		Description: "times resumed",
-		LabelName:   "action",
-		LabelValue:  a.Name,
+		Labels:      labels,
 	}
 
-	return points
+	return validPoints(points)
+}
+
+// labels returns the labels of the series of the action: its name, and the
+// module and index of actions without a name of their own.
+func (a *Action) labels() []model.Label {
+	n := ParseName(a.Name)
+	return []model.Label{
+		{Name: "action", Value: a.Name},
+		{Name: "module", Value: n.Module},
+		{Name: "action_index", Value: n.Action},
+	}
 }
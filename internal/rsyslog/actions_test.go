@@ -39,24 +39,24 @@ func TestNewActionFromJSON(t *testing.T) {
 		t.Errorf(th.ExpectedActualStringFmt, want, got)
 	}
 
-	if want, got := int64(100000), pstat.Processed; want != got {
-		t.Errorf(th.ExpectedActualIntFmt, want, got)
+	if want, got := Number(100000), pstat.Processed; want != got {
+		t.Errorf(th.ExpectedActualFloatFmt, want, got)
 	}
 
-	if want, got := int64(2), pstat.Failed; want != got {
-		t.Errorf(th.ExpectedActualIntFmt, want, got)
+	if want, got := Number(2), pstat.Failed; want != got {
+		t.Errorf(th.ExpectedActualFloatFmt, want, got)
 	}
 
-	if want, got := int64(1), pstat.Suspended; want != got {
-		t.Errorf(th.ExpectedActualIntFmt, want, got)
+	if want, got := Number(1), pstat.Suspended; want != got {
+		t.Errorf(th.ExpectedActualFloatFmt, want, got)
 	}
 
-	if want, got := int64(1000), pstat.SuspendedDuration; want != got {
-		t.Errorf(th.ExpectedActualIntFmt, want, got)
+	if want, got := Number(1000), pstat.SuspendedDuration; want != got {
+		t.Errorf(th.ExpectedActualFloatFmt, want, got)
 	}
 
-	if want, got := int64(1), pstat.Resumed; want != got {
-		t.Errorf(th.ExpectedActualIntFmt, want, got)
+	if want, got := Number(1), pstat.Resumed; want != got {
+		t.Errorf(th.ExpectedActualFloatFmt, want, got)
 	}
 }
 
@@ -70,7 +70,7 @@ func TestActionToPoints(t *testing.T) {
 	type expectation struct {
 		idx        int
 		name       string
-		value      int64
+		value      float64
 		metricType model.PointType
 		labelValue string
 	}
@@ -88,7 +88,7 @@ func TestActionToPoints(t *testing.T) {
 		}
 		pt := points[exp.idx]
 		want := th.PointExpectation{Name: exp.name, Type: int(exp.metricType), Value: exp.value, Label: exp.labelValue}
-		got := th.PointExpectation{Name: pt.Name, Type: int(pt.Type), Value: pt.Value, Label: pt.LabelValue}
+		got := th.PointExpectation{Name: pt.Name, Type: int(pt.Type), Value: pt.Value, Label: pt.Label("action")}
 		th.AssertPointFields(t, exp.idx, want, got)
 	}
 }
@@ -14,7 +14,6 @@
 package rsyslog
 
 import (
-	"encoding/json"
 	"fmt"
 
 	"github.com/prometheus-community/rsyslog_exporter/internal/model"
@@ -22,18 +21,34 @@ import (
 
 // DynStat represents rsyslog dynamic statistics buckets.
 type DynStat struct {
-	Name   string           `json:"name"`
-	Origin string           `json:"origin"`
-	Values map[string]int64 `json:"values"`
+	Name   string            `json:"name"`
+	Origin string            `json:"origin"`
+	Values map[string]Number `json:"values"`
 }
 
 func NewDynStatFromJSON(b []byte) (*DynStat, error) {
-	var pstat DynStat
-	err := json.Unmarshal(b, &pstat)
-	if err != nil {
+	var f Fields
+	if err := f.Parse(b); err != nil {
 		return nil, fmt.Errorf("error decoding values stat `%v`: %w", string(b), err)
 	}
-	return &pstat, nil
+	return NewDynStatFromFields(&f), nil
+}
+
+// NewDynStatFromFields returns the counters of the dynstats bucket in the
+// parsed object f.
+func NewDynStatFromFields(f *Fields) *DynStat {
+	pstat := &DynStat{
+		Name:   f.String("name"),
+		Origin: f.String("origin"),
+	}
+	var values Fields
+	if values.splitObject(f.Value("values")) {
+		pstat.Values = make(map[string]Number, values.Len())
+		values.Range(func(key, value []byte) {
+			pstat.Values[string(key)] = parseNumber(value)
+		})
+	}
+	return pstat
 }
 
 func (i *DynStat) ToPoints() []*model.Point {
@@ -43,12 +58,11 @@ func (i *DynStat) ToPoints() []*model.Point {
 		points = append(points, &model.Point{
 			Name:        fmt.Sprintf("dynstat_%s", i.Name),
 			Type:        model.Counter,
-			Value:       value,
+			Value:       float64(value),
 			Description: fmt.Sprintf("dynamic statistics bucket %s", i.Name),
-			LabelName:   "counter",
-			LabelValue:  name,
+			Labels:      []model.Label{{Name: "counter", Value: name}},
 		})
 	}
 
-	return points
+	return validPoints(points)
 }
@@ -23,7 +23,7 @@ import (
 
 func TestGetDynStat(t *testing.T) {
 	log := []byte(`{ "name": "global", "origin": "dynstats", "values": { "` + th.MsgPerHostOpsOverflow + `": 1, "` + th.MsgPerHostNewMetricAdd + `": 3, "` + th.MsgPerHostNoMetric + `": 0, "` + th.MsgPerHostMetricsPurged + `": 0, "` + th.MsgPerHostOpsIgnored + `": 0 } }`)
-	values := map[string]int64{
+	values := map[string]Number{
 		th.MsgPerHostOpsOverflow:   1,
 		th.MsgPerHostNewMetricAdd:  3,
 		th.MsgPerHostNoMetric:      0,
@@ -55,40 +55,35 @@ func TestDynStatToPoints(t *testing.T) {
 			Type:        model.Counter,
 			Value:       1,
 			Description: th.DynStatBucketDesc,
-			LabelName:   "counter",
-			LabelValue:  th.MsgPerHostOpsOverflow,
+			Labels:      []model.Label{{Name: "counter", Value: th.MsgPerHostOpsOverflow}},
 		},
 		"msg_per_host.new_metric_add": {
 			Name:        "dynstat_global",
 			Type:        model.Counter,
 			Value:       3,
 			Description: th.DynStatBucketDesc,
-			LabelName:   "counter",
-			LabelValue:  th.MsgPerHostNewMetricAdd,
+			Labels:      []model.Label{{Name: "counter", Value: th.MsgPerHostNewMetricAdd}},
 		},
 		"msg_per_host.no_metric": {
 			Name:        "dynstat_global",
 			Type:        model.Counter,
 			Value:       0,
 			Description: th.DynStatBucketDesc,
-			LabelName:   "counter",
-			LabelValue:  th.MsgPerHostNoMetric,
+			Labels:      []model.Label{{Name: "counter", Value: th.MsgPerHostNoMetric}},
 		},
 		"msg_per_host.metrics_purged": {
 			Name:        "dynstat_global",
 			Type:        model.Counter,
 			Value:       0,
 			Description: th.DynStatBucketDesc,
-			LabelName:   "counter",
-			LabelValue:  th.MsgPerHostMetricsPurged,
+			Labels:      []model.Label{{Name: "counter", Value: th.MsgPerHostMetricsPurged}},
 		},
 		"msg_per_host.ops_ignored": {
 			Name:        "dynstat_global",
 			Type:        model.Counter,
 			Value:       0,
 			Description: th.DynStatBucketDesc,
-			LabelName:   "counter",
-			LabelValue:  th.MsgPerHostOpsIgnored,
+			Labels:      []model.Label{{Name: "counter", Value: th.MsgPerHostOpsIgnored}},
 		},
 	}
 
@@ -104,7 +99,7 @@ func TestDynStatToPoints(t *testing.T) {
 
 	points := pstat.ToPoints()
 	for _, got := range points {
-		key := got.LabelValue
+		key := got.Label("counter")
 		want, ok := wants[key]
 		if !ok {
 			t.Errorf("unexpected point, got: %+v", got)
@@ -14,9 +14,7 @@
 package rsyslog
 
 import (
-	"encoding/json"
 	"fmt"
-	"strings"
 
 	"github.com/prometheus-community/rsyslog_exporter/internal/model"
 )
@@ -25,22 +23,38 @@ import (
 type DfcStat struct {
 	Name          string `json:"name"`
 	Origin        string `json:"origin"`
-	Requests      int64  `json:"requests"`
-	Level0        int64  `json:"level0"`
-	Missed        int64  `json:"missed"`
-	Evicted       int64  `json:"evicted"`
-	MaxUsed       int64  `json:"maxused"`
-	CloseTimeouts int64  `json:"closetimeouts"`
+	Requests      Number `json:"requests"`
+	Level0        Number `json:"level0"`
+	Missed        Number `json:"missed"`
+	Evicted       Number `json:"evicted"`
+	MaxUsed       Number `json:"maxused"`
+	CloseTimeouts Number `json:"closetimeouts"`
 }
 
 func NewDynafileCacheFromJSON(b []byte) (*DfcStat, error) {
-	var pstat DfcStat
-	err := json.Unmarshal(b, &pstat)
-	if err != nil {
+	var f Fields
+	if err := f.Parse(b); err != nil {
 		return nil, fmt.Errorf("error decoding dynafile cache stat `%v`: %w", string(b), err)
 	}
-	pstat.Name = strings.TrimPrefix(pstat.Name, "dynafile cache ")
-	return &pstat, nil
+	return NewDynafileCacheFromFields(&f), nil
+}
+
+// NewDynafileCacheFromFields returns the dynafile cache stats of the parsed object f.
+func NewDynafileCacheFromFields(f *Fields) *DfcStat {
+	pstat := &DfcStat{
+		Name:          f.String("name"),
+		Origin:        f.String("origin"),
+		Requests:      f.Number("requests"),
+		Level0:        f.Number("level0"),
+		Missed:        f.Number("missed"),
+		Evicted:       f.Number("evicted"),
+		MaxUsed:       f.Number("maxused"),
+		CloseTimeouts: f.Number("closetimeouts"),
+	}
+	if n := ParseName(pstat.Name); n.Cache != "" {
+		pstat.Name = n.Cache
+	}
+	return pstat
 }
 
 func (d *DfcStat) ToPoints() []*model.Point {
@@ -49,51 +63,45 @@ func (d *DfcStat) ToPoints() []*model.Point {
 	points[0] = &model.Point{
 		Name:        "dynafile_cache_requests",
 		Type:        model.Counter,
-		Value:       d.Requests,
+		Value:       float64(d.Requests),
 		Description: "number of requests made to obtain a dynafile",
-		LabelName:   "cache",
-		LabelValue:  d.Name,
+		Labels:      []model.Label{{Name: "cache", Value: d.Name}},
 	}
 	points[1] = &model.Point{
 		Name:        "dynafile_cache_level0",
 		Type:        model.Counter,
-		Value:       d.Level0,
+		Value:       float64(d.Level0),
 		Description: "number of requests for the current active file",
-		LabelName:   "cache",
-		LabelValue:  d.Name,
+		Labels:      []model.Label{{Name: "cache", Value: d.Name}},
 	}
 	points[2] = &model.Point{
 		Name:        "dynafile_cache_missed",
 		Type:        model.Counter,
-		Value:       d.Missed,
+		Value:       float64(d.Missed),
 		Description: "number of cache misses",
-		LabelName:   "cache",
-		LabelValue:  d.Name,
+		Labels:      []model.Label{{Name: "cache", Value: d.Name}},
 	}
 	points[3] = &model.Point{
 		Name:        "dynafile_cache_evicted",
 		Type:        model.Counter,
-		Value:       d.Evicted,
+		Value:       float64(d.Evicted),
 		Description: "number of times a file needed to be evicted from cache",
-		LabelName:   "cache",
-		LabelValue:  d.Name,
+		Labels:      []model.Label{{Name: "cache", Value: d.Name}},
 	}
 	points[4] = &model.Point{
 		Name:        "dynafile_cache_maxused",
 		Type:        model.Counter,
-		Value:       d.MaxUsed,
+		Value:       float64(d.MaxUsed),
 		Description: "maximum number of cache entries ever used",
-		LabelName:   "cache",
-		LabelValue:  d.Name,
+		Labels:      []model.Label{{Name: "cache", Value: d.Name}},
 	}
 	points[5] = &model.Point{
 		Name:        "dynafile_cache_closetimeouts",
 		Type:        model.Counter,
-		Value:       d.CloseTimeouts,
+		Value:       float64(d.CloseTimeouts),
 		Description: "number of times a file was closed due to timeout settings",
-		LabelName:   "cache",
-		LabelValue:  d.Name,
+		Labels:      []model.Label{{Name: "cache", Value: d.Name}},
 	}
 
-	return points
+	return validPoints(points)
 }
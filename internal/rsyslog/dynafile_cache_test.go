@@ -36,7 +36,7 @@ func TestNewDynafileCacheFromJSON(t *testing.T) {
 	th.AssertEqString(t, "name", th.Cluster, pstat.Name)
 	nums := []struct {
 		ctx       string
-		want, got int64
+		want, got Number
 	}{
 		{"requests", 1783254, pstat.Requests},
 		{"level0", 1470906, pstat.Level0},
@@ -46,18 +46,18 @@ func TestNewDynafileCacheFromJSON(t *testing.T) {
 		{"closetimeouts", 10, pstat.CloseTimeouts},
 	}
 	for _, n := range nums {
-		th.AssertEqInt(t, n.ctx, n.want, n.got)
+		th.AssertEqFloat(t, n.ctx, float64(n.want), float64(n.got))
 	}
 }
 
 func TestDynafileCacheToPoints(t *testing.T) {
 	expected := []model.Point{
-		{Name: "dynafile_cache_requests", Type: model.Counter, Value: 1783254, Description: "number of requests made to obtain a dynafile", LabelName: "cache", LabelValue: th.Cluster},
-		{Name: "dynafile_cache_level0", Type: model.Counter, Value: 1470906, Description: "number of requests for the current active file", LabelName: "cache", LabelValue: th.Cluster},
-		{Name: "dynafile_cache_missed", Type: model.Counter, Value: 2625, Description: "number of cache misses", LabelName: "cache", LabelValue: th.Cluster},
-		{Name: "dynafile_cache_evicted", Type: model.Counter, Value: 2525, Description: "number of times a file needed to be evicted from cache", LabelName: "cache", LabelValue: th.Cluster},
-		{Name: "dynafile_cache_maxused", Type: model.Counter, Value: 100, Description: "maximum number of cache entries ever used", LabelName: "cache", LabelValue: th.Cluster},
-		{Name: "dynafile_cache_closetimeouts", Type: model.Counter, Value: 10, Description: "number of times a file was closed due to timeout settings", LabelName: "cache", LabelValue: th.Cluster},
+		{Name: "dynafile_cache_requests", Type: model.Counter, Value: 1783254, Description: "number of requests made to obtain a dynafile", Labels: []model.Label{{Name: "cache", Value: th.Cluster}}},
+		{Name: "dynafile_cache_level0", Type: model.Counter, Value: 1470906, Description: "number of requests for the current active file", Labels: []model.Label{{Name: "cache", Value: th.Cluster}}},
+		{Name: "dynafile_cache_missed", Type: model.Counter, Value: 2625, Description: "number of cache misses", Labels: []model.Label{{Name: "cache", Value: th.Cluster}}},
+		{Name: "dynafile_cache_evicted", Type: model.Counter, Value: 2525, Description: "number of times a file needed to be evicted from cache", Labels: []model.Label{{Name: "cache", Value: th.Cluster}}},
+		{Name: "dynafile_cache_maxused", Type: model.Counter, Value: 100, Description: "maximum number of cache entries ever used", Labels: []model.Label{{Name: "cache", Value: th.Cluster}}},
+		{Name: "dynafile_cache_closetimeouts", Type: model.Counter, Value: 10, Description: "number of times a file was closed due to timeout settings", Labels: []model.Label{{Name: "cache", Value: th.Cluster}}},
 	}
 	pstat, err := NewDynafileCacheFromJSON(dynafileCacheLog)
 	if err != nil {
@@ -73,8 +73,8 @@ func TestDynafileCacheToPoints(t *testing.T) {
 		if exp.Name != got.Name {
 			t.Errorf(th.ExpectedActualStringFmt, exp.Name, got.Name)
 		}
-		th.AssertEqInt(t, exp.Name+" value", exp.Value, got.Value)
-		th.AssertEqString(t, exp.Name+" label", exp.LabelValue, got.LabelValue)
+		th.AssertEqFloat(t, exp.Name+" value", exp.Value, got.Value)
+		th.AssertEqString(t, exp.Name+" label", exp.Label("cache"), got.Label("cache"))
 		if exp.Type != got.Type {
 			t.Errorf(exp.Name+": expected type %v, got %v", exp.Type, got.Type)
 		}
@@ -0,0 +1,185 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rsyslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// ErrNotObject is returned when parsing JSON that is not an object.
+var ErrNotObject = errors.New("not a JSON object")
+
+// field is a top-level field of a JSON object, both parts referencing the
+// object. The key is the text between its quotes, the value raw JSON.
+type field struct {
+	key   []byte
+	value []byte
+}
+
+// Fields is a JSON object split into its top-level fields without decoding
+// their values, so that classification and decoding share a single pass
+// over each stats line. A Fields may be reused for many objects; its
+// content stays valid until the parsed buffer is modified.
+type Fields struct {
+	fields []field
+}
+
+// Parse splits the JSON object buf into f, replacing its previous content.
+func (f *Fields) Parse(buf []byte) error {
+	f.fields = f.fields[:0]
+	if !json.Valid(buf) {
+		// only the error path pays for decoding, to report where buf is
+		// invalid
+		var v struct{}
+		return json.Unmarshal(buf, &v)
+	}
+	i := skipSpace(buf, 0)
+	if buf[i] != '{' {
+		return ErrNotObject
+	}
+	f.split(buf, i)
+	return nil
+}
+
+// splitObject splits the raw value v of a field of a parsed object into
+// f, replacing its previous content. It reports whether v is an object.
+func (f *Fields) splitObject(v []byte) bool {
+	f.fields = f.fields[:0]
+	if len(v) == 0 || v[0] != '{' {
+		return false
+	}
+	f.split(v, 0)
+	return true
+}
+
+// split records the fields of the valid JSON object starting at buf[i].
+func (f *Fields) split(buf []byte, i int) {
+	i++
+	for {
+		i = skipSpace(buf, i)
+		switch buf[i] {
+		case '}':
+			return
+		case ',':
+			i = skipSpace(buf, i+1)
+		}
+		end := stringEnd(buf, i)
+		key := buf[i+1 : end]
+		i = skipSpace(buf, end+1) // at the colon
+		i = skipSpace(buf, i+1)
+		vend := valueEnd(buf, i)
+		f.fields = append(f.fields, field{key: key, value: buf[i:vend]})
+		i = vend
+	}
+}
+
+// skipSpace returns the index of the first non-blank byte from i on.
+func skipSpace(buf []byte, i int) int {
+	for i < len(buf) {
+		switch buf[i] {
+		case ' ', '\t', '\r', '\n':
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// valueEnd returns the index just past the valid JSON value at buf[i].
+func valueEnd(buf []byte, i int) int {
+	switch buf[i] {
+	case '"':
+		return stringEnd(buf, i) + 1
+	case '{', '[':
+		depth := 0
+		for ; i < len(buf); i++ {
+			switch buf[i] {
+			case '"':
+				i = stringEnd(buf, i)
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					return i + 1
+				}
+			}
+		}
+		return i
+	}
+	for ; i < len(buf); i++ {
+		switch buf[i] {
+		case ',', '}', ']', ' ', '\t', '\r', '\n':
+			return i
+		}
+	}
+	return i
+}
+
+// Len returns the number of fields.
+func (f *Fields) Len() int {
+	return len(f.fields)
+}
+
+// Range calls fn with the key and raw value of every field, in order.
+func (f *Fields) Range(fn func(key, value []byte)) {
+	for _, fd := range f.fields {
+		fn(fd.key, fd.value)
+	}
+}
+
+// Value returns the raw JSON value of the field key, or nil if there is
+// none.
+func (f *Fields) Value(key string) []byte {
+	for _, fd := range f.fields {
+		if string(fd.key) == key {
+			return fd.value
+		}
+	}
+	return nil
+}
+
+// Has reports whether there is a field key.
+func (f *Fields) Has(key string) bool {
+	return f.Value(key) != nil
+}
+
+// String returns the value of the string field key, or "" if it is
+// missing or not a string.
+func (f *Fields) String(key string) string {
+	return stringValue(f.Value(key))
+}
+
+// Number returns the value of the field key, 0 if it is missing, or NaN if
+// it is not a number, see Number.
+func (f *Fields) Number(key string) Number {
+	return parseNumber(f.Value(key))
+}
+
+// stringValue decodes the raw JSON string v, or returns "" if v is not a
+// string.
+func stringValue(v []byte) string {
+	if len(v) < 2 || v[0] != '"' {
+		return ""
+	}
+	if bytes.IndexByte(v, '\\') < 0 {
+		return string(v[1 : len(v)-1])
+	}
+	var s string
+	_ = json.Unmarshal(v, &s)
+	return s
+}
@@ -0,0 +1,96 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rsyslog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	th "github.com/prometheus-community/rsyslog_exporter/internal/testhelpers"
+)
+
+func TestFieldsParse(t *testing.T) {
+	var f Fields
+	buf := []byte(` { "name" : "a \"b\"", "values": {"x": 1, "y": [1, "}"]}, "n":-1.5e3 ,"s":"}" } `)
+	if err := f.Parse(buf); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	th.AssertEqInt(t, "fields", 4, int64(f.Len()))
+	th.AssertEqString(t, "name", `a "b"`, f.String("name"))
+	th.AssertEqString(t, "values", `{"x": 1, "y": [1, "}"]}`, string(f.Value("values")))
+	th.AssertEqFloat(t, "n", -1500, float64(f.Number("n")))
+	th.AssertEqString(t, "s", "}", f.String("s"))
+	if f.Has("missing") || f.String("n") != "" {
+		t.Errorf("expected no string for missing and numeric fields")
+	}
+
+	var keys []string
+	f.Range(func(key, _ []byte) { keys = append(keys, string(key)) })
+	th.AssertEqString(t, "keys", "[name values n s]", fmt.Sprint(keys))
+
+	// reuse drops the previous fields
+	if err := f.Parse([]byte(`{}`)); err != nil || f.Len() != 0 {
+		t.Errorf("expected no fields, got %d (err %v)", f.Len(), err)
+	}
+}
+
+func TestFieldsParseErrors(t *testing.T) {
+	var f Fields
+	var syntaxErr *json.SyntaxError
+	if err := f.Parse([]byte(`{"name":`)); !errors.As(err, &syntaxErr) {
+		t.Errorf("want SyntaxError, got %v", err)
+	}
+	for _, buf := range []string{`[1]`, `"x"`, `1`} {
+		if err := f.Parse([]byte(buf)); !errors.Is(err, ErrNotObject) {
+			t.Errorf("Parse(%s): want ErrNotObject, got %v", buf, err)
+		}
+	}
+}
+
+var benchmarkLines = [][]byte{
+	[]byte(`{"name":"main Q","origin":"core.queue","size":10,"enqueued":4032,"full":0,"discarded.full":0,"discarded.nf":0,"maxqsize":26}`),
+	[]byte(`{"name":"test_action","origin":"core.action","processed":100000,"failed":2,"suspended":1,"suspended.duration":1000,"resumed":1}`),
+	[]byte(`{"name":"global","origin":"dynstats","values":{"msg_per_host.ops_overflow":1,"msg_per_host.new_metric_add":3,"msg_per_host.no_metric":0,"msg_per_host.metrics_purged":0,"msg_per_host.ops_ignored":0}}`),
+}
+
+func BenchmarkClassify(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, line := range benchmarkLines {
+			Classify(line)
+		}
+	}
+}
+
+func BenchmarkParseAndDecode(b *testing.B) {
+	var f Fields
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, line := range benchmarkLines {
+			if err := f.Parse(line); err != nil {
+				b.Fatal(err)
+			}
+			switch ClassifyFields(&f).Type {
+			case TypeQueue:
+				NewQueueFromFields(&f).ToPoints()
+			case TypeAction:
+				NewActionFromFields(&f).ToPoints()
+			case TypeDynStat:
+				NewDynStatFromFields(&f).ToPoints()
+			}
+		}
+	}
+}
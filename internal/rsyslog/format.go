@@ -0,0 +1,253 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rsyslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Format is an impstats output format as selected by its "format" parameter.
+type Format int
+
+const (
+	// FormatAuto detects the format of every line on its own.
+	FormatAuto Format = iota
+	FormatJSON
+	FormatCEE
+	FormatJSONElasticsearch
+	FormatLegacy
+)
+
+var formatNames = map[Format]string{
+	FormatAuto:              "auto",
+	FormatJSON:              "json",
+	FormatCEE:               "cee",
+	FormatJSONElasticsearch: "json-elasticsearch",
+	FormatLegacy:            "legacy",
+}
+
+var (
+	ErrUnknownFormat = errors.New("unknown impstats format")
+	ErrInvalidLegacy = errors.New("invalid legacy impstats line")
+)
+
+// ceeCookie prefixes impstats objects in cee format.
+var ceeCookie = []byte("@cee:")
+
+// ParseFormat returns the Format named s: "auto" or an impstats format name.
+func ParseFormat(s string) (Format, error) {
+	for f, name := range formatNames {
+		if name == s {
+			return f, nil
+		}
+	}
+	return FormatAuto, fmt.Errorf("%w %q", ErrUnknownFormat, s)
+}
+
+func (f Format) String() string {
+	if name, ok := formatNames[f]; ok {
+		return name
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// Normalize converts an impstats object in format f into the plain JSON
+// understood by StatType and the decoders. With FormatAuto the format is
+// detected from the payload itself.
+func Normalize(buf []byte, f Format) ([]byte, error) {
+	if f == FormatAuto {
+		f = detectFormat(buf)
+	}
+	switch f {
+	case FormatJSON:
+		return buf, nil
+	case FormatCEE:
+		return bytes.TrimLeft(bytes.TrimPrefix(bytes.TrimLeft(buf, " "), ceeCookie), " "), nil
+	case FormatJSONElasticsearch:
+		return restoreDottedKeys(buf), nil
+	case FormatLegacy:
+		return legacyToJSON(buf)
+	}
+	return nil, fmt.Errorf("%w: %v", ErrUnknownFormat, f)
+}
+
+// detectFormat guesses the format of a single impstats object.
+func detectFormat(buf []byte) Format {
+	trimmed := bytes.TrimLeft(buf, " ")
+	switch {
+	case bytes.HasPrefix(trimmed, ceeCookie):
+		return FormatCEE
+	case len(trimmed) > 0 && trimmed[0] == '{':
+		if hasMangledKey(trimmed) {
+			return FormatJSONElasticsearch
+		}
+		return FormatJSON
+	case bytes.Contains(trimmed, []byte(": ")) && bytes.IndexByte(trimmed, '=') >= 0:
+		return FormatLegacy
+	}
+	// not recognisable; let classification report it as unknown
+	return FormatJSON
+}
+
+// mangledKeys are the json-elasticsearch forms of dotted keys rsyslog itself
+// emits: full keys, or the suffixes of dynstats bookkeeping counters, which
+// follow the user's bucket name. Other keys containing "!", such as
+// dynstats counters, are user data and do not tell the formats apart.
+var mangledKeys = [][]byte{
+	[]byte(`"discarded!full"`),
+	[]byte(`"discarded!nf"`),
+	[]byte(`"suspended!duration"`),
+	[]byte(`"called!recvmmsg"`),
+	[]byte(`"called!recvmsg"`),
+	[]byte(`"msgs!received"`),
+	[]byte(`"bytes!sent"`),
+	[]byte(`"topicdynacache!`),
+	[]byte(`!ops_overflow"`),
+	[]byte(`!new_metric_add"`),
+	[]byte(`!no_metric"`),
+	[]byte(`!metrics_purged"`),
+	[]byte(`!ops_ignored"`),
+	[]byte(`!purge_triggered"`),
+}
+
+// hasMangledKey reports whether the JSON object buf has a key mangled by
+// json-elasticsearch, which only differs from json by "!" replacing dots in
+// keys.
+func hasMangledKey(buf []byte) bool {
+	if bytes.IndexByte(buf, '!') < 0 {
+		return false
+	}
+	for _, k := range mangledKeys {
+		if bytes.Contains(buf, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// restoreDottedKeys undoes the json-elasticsearch key mangling by replacing
+// "!" with "." in every object key, e.g. "discarded!full". String values
+// are left untouched.
+func restoreDottedKeys(buf []byte) []byte {
+	var out []byte
+	for i := 0; i < len(buf); i++ {
+		if buf[i] != '"' {
+			continue
+		}
+		end := stringEnd(buf, i)
+		if end < 0 {
+			break
+		}
+		if isKey(buf, end+1) && bytes.IndexByte(buf[i:end], '!') >= 0 {
+			if out == nil {
+				out = bytes.Clone(buf)
+			}
+			for j := i; j < end; j++ {
+				if out[j] == '!' {
+					out[j] = '.'
+				}
+			}
+		}
+		i = end
+	}
+	if out == nil {
+		return buf
+	}
+	return out
+}
+
+// stringEnd returns the index of the quote closing the JSON string that
+// starts at buf[start], or -1 if it is not terminated.
+func stringEnd(buf []byte, start int) int {
+	for i := start + 1; i < len(buf); i++ {
+		switch buf[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// isKey reports whether the next non-blank byte from pos on is a colon,
+// i.e. whether the string before pos is an object key.
+func isKey(buf []byte, pos int) bool {
+	for ; pos < len(buf); pos++ {
+		switch buf[pos] {
+		case ' ', '\t', '\r', '\n':
+		case ':':
+			return true
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// legacyToJSON converts a legacy impstats line such as
+// "main Q: origin=core.queue size=0 enqueued=31" into the equivalent JSON
+// object. Counters of dynstats objects are nested under "values" as in
+// the JSON formats.
+func legacyToJSON(buf []byte) ([]byte, error) {
+	line := strings.TrimSpace(string(buf))
+	eq := strings.IndexByte(line, '=')
+	if eq < 0 {
+		return nil, fmt.Errorf("%w: no key=value pairs in %q", ErrInvalidLegacy, line)
+	}
+	// names may contain ": " themselves, so split at the last one before
+	// the first key.
+	sep := strings.LastIndex(line[:eq], ": ")
+	if sep < 0 {
+		return nil, fmt.Errorf("%w: no object name in %q", ErrInvalidLegacy, line)
+	}
+
+	obj := map[string]any{"name": line[:sep]}
+	values := obj
+	for _, pair := range strings.Fields(line[sep+2:]) {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("%w: malformed pair %q", ErrInvalidLegacy, pair)
+		}
+		if k == "origin" {
+			obj[k] = v
+			if strings.HasPrefix(v, "dynstats") {
+				values = map[string]any{}
+				obj["values"] = values
+			}
+			continue
+		}
+		if isInteger(v) {
+			values[k] = json.Number(v)
+		} else {
+			values[k] = v
+		}
+	}
+	return json.Marshal(obj)
+}
+
+// isInteger reports whether s is a decimal integer as impstats prints its
+// counters, including values beyond the int64 range.
+func isInteger(s string) bool {
+	if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return true
+	}
+	_, err := strconv.ParseUint(s, 10, 64)
+	return err == nil
+}
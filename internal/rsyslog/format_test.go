@@ -0,0 +1,111 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package rsyslog
+
+import (
+	"errors"
+	"testing"
+
+	th "github.com/prometheus-community/rsyslog_exporter/internal/testhelpers"
+)
+
+func TestParseFormat(t *testing.T) {
+	for f, name := range formatNames {
+		got, err := ParseFormat(name)
+		if err != nil || got != f {
+			t.Errorf("ParseFormat(%q) = %v, %v", name, got, err)
+		}
+		th.AssertEqString(t, "String", name, f.String())
+	}
+	if _, err := ParseFormat("xml"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("expected ErrUnknownFormat, got %v", err)
+	}
+	th.AssertEqString(t, "unknown String", "Format(42)", Format(42).String())
+}
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		name   string
+		format Format
+		in     string
+		want   string
+	}{
+		{"json", FormatJSON, string(queueStat), string(queueStat)},
+		{"auto json", FormatAuto, string(queueStat), string(queueStat)},
+		{"cee", FormatCEE, "@cee: " + string(queueStat), string(queueStat)},
+		{"auto cee", FormatAuto, "@cee:" + string(queueStat), string(queueStat)},
+		{"elasticsearch", FormatJSONElasticsearch,
+			`{"name":"main Q!x","discarded!full":40, "discarded!nf" :50}`,
+			`{"name":"main Q!x","discarded.full":40, "discarded.nf" :50}`},
+		{"auto elasticsearch", FormatAuto,
+			`{"name":"global","origin":"dynstats","values":{"msg_per_host!ops_overflow":1}}`,
+			`{"name":"global","origin":"dynstats","values":{"msg_per_host.ops_overflow":1}}`},
+		{"auto elasticsearch queue", FormatAuto,
+			`{"name":"main Q","origin":"core.queue","discarded!full":40}`,
+			`{"name":"main Q","origin":"core.queue","discarded.full":40}`},
+		// user keys containing "!" are no sign of json-elasticsearch
+		{"auto dynstats bang", FormatAuto,
+			`{"name":"msg_per_host","origin":"dynstats.bucket","values":{"host!a":1}}`,
+			`{"name":"msg_per_host","origin":"dynstats.bucket","values":{"host!a":1}}`},
+		{"elasticsearch escaped quote", FormatJSONElasticsearch,
+			`{"name":"a\"!b","x!y":1}`,
+			`{"name":"a\"!b","x.y":1}`},
+		{"legacy", FormatLegacy,
+			"main Q: origin=core.queue size=10 enqueued=20 discarded.full=18446744073709551615",
+			`{"discarded.full":18446744073709551615,"enqueued":20,"name":"main Q","origin":"core.queue","size":10}`},
+		{"auto legacy", FormatAuto,
+			"mmkubernetes(https://k8s:443): origin=mmkubernetes recordseen=4",
+			`{"name":"mmkubernetes(https://k8s:443)","origin":"mmkubernetes","recordseen":4}`},
+		{"legacy dynstats", FormatLegacy,
+			"global: origin=dynstats msg_per_host.ops_overflow=1 msg_per_host.new_metric_add=3",
+			`{"name":"global","origin":"dynstats","values":{"msg_per_host.new_metric_add":3,"msg_per_host.ops_overflow":1}}`},
+		{"legacy string value", FormatLegacy, "x: origin=foo mode=fast n=1",
+			`{"mode":"fast","n":1,"name":"x","origin":"foo"}`},
+		{"auto unrecognised", FormatAuto, "garbage", "garbage"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := Normalize([]byte(c.in), c.format)
+			if err != nil {
+				t.Fatalf("Normalize failed: %v", err)
+			}
+			th.AssertEqString(t, c.name, c.want, string(got))
+		})
+	}
+}
+
+func TestNormalizeErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		format Format
+		in     string
+		want   error
+	}{
+		{"legacy without pairs", FormatLegacy, "main Q: nothing", ErrInvalidLegacy},
+		{"legacy without name", FormatLegacy, "size=1", ErrInvalidLegacy},
+		{"legacy malformed pair", FormatLegacy, "main Q: size=1 =2", ErrInvalidLegacy},
+		{"unknown format", Format(42), "{}", ErrUnknownFormat},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := Normalize([]byte(c.in), c.format); !errors.Is(err, c.want) {
+				t.Fatalf("expected %v, got %v", c.want, err)
+			}
+		})
+	}
+}
+
+func TestRestoreDottedKeysUnterminated(t *testing.T) {
+	in := `{"a!b":1,"unterminated`
+	th.AssertEqString(t, "unterminated", `{"a.b":1,"unterminated`, string(restoreDottedKeys([]byte(in))))
+}
@@ -14,7 +14,6 @@
 package rsyslog
 
 import (
-	"encoding/json"
 	"fmt"
 
 	"github.com/prometheus-community/rsyslog_exporter/internal/model"
@@ -23,16 +22,23 @@ import (
 // Forward represents rsyslog forwarding statistics.
 type Forward struct {
 	Name      string `json:"name"`
-	BytesSent int64  `json:"bytes.sent"`
+	BytesSent Number `json:"bytes.sent"`
 }
 
 func NewForwardFromJSON(b []byte) (*Forward, error) {
-	var pstat Forward
-	err := json.Unmarshal(b, &pstat)
-	if err != nil {
+	var f Fields
+	if err := f.Parse(b); err != nil {
 		return nil, fmt.Errorf("failed to decode forward stat `%v`: %w", string(b), err)
 	}
-	return &pstat, nil
+	return NewForwardFromFields(&f), nil
+}
+
+// NewForwardFromFields returns the forward stats of the parsed object f.
+func NewForwardFromFields(f *Fields) *Forward {
+	return &Forward{
+		Name:      f.String("name"),
+		BytesSent: f.Number("bytes.sent"),
+	}
 }
 
 func (f *Forward) ToPoints() []*model.Point {
@@ -41,11 +47,10 @@ func (f *Forward) ToPoints() []*model.Point {
 	points[0] = &model.Point{
 		Name:        "forward_bytes_total",
 		Type:        model.Counter,
-		Value:       f.BytesSent,
+		Value:       float64(f.BytesSent),
 		Description: "bytes forwarded to destination",
-		LabelName:   "destination",
-		LabelValue:  f.Name,
+		Labels:      []model.Label{{Name: "destination", Value: f.Name}},
 	}
 
-	return points
+	return validPoints(points)
 }
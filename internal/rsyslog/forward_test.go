@@ -34,7 +34,7 @@ func TestNewForwardFromJSON(t *testing.T) {
 		t.Fatalf("parse forward stat failed: %v", err)
 	}
 	th.AssertEqString(t, "name", "TCP-FQDN-6514", pstat.Name)
-	th.AssertEqInt(t, "bytes_sent", 666, pstat.BytesSent)
+	th.AssertEqFloat(t, "bytes_sent", 666, float64(pstat.BytesSent))
 }
 
 func TestForwardToPoints(t *testing.T) {
@@ -48,8 +48,8 @@ func TestForwardToPoints(t *testing.T) {
 	}
 	p := points[0]
 	th.AssertEqString(t, "point name", "forward_bytes_total", p.Name)
-	th.AssertEqInt(t, "point value", 666, p.Value)
-	th.AssertEqString(t, "point label", "TCP-FQDN-6514", p.LabelValue)
+	th.AssertEqFloat(t, "point value", 666, p.Value)
+	th.AssertEqString(t, "point label", "TCP-FQDN-6514", p.Label("destination"))
 	if p.Type != model.Counter {
 		t.Errorf("point type: expected %v, got %v", model.Counter, p.Type)
 	}
@@ -0,0 +1,93 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rsyslog
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/prometheus-community/rsyslog_exporter/internal/model"
+)
+
+// Generic represents an impstats object without a dedicated decoder. All
+// its numeric fields are kept; fields of nested objects are named by their
+// dotted path, e.g. "values.foo".
+type Generic struct {
+	Name   string
+	Origin string
+	Values map[string]Number
+}
+
+func NewGenericFromJSON(b []byte) (*Generic, error) {
+	var f Fields
+	if err := f.Parse(b); err != nil {
+		return nil, fmt.Errorf("failed to decode generic stat `%v`: %w", string(b), err)
+	}
+	return NewGenericFromFields(&f), nil
+}
+
+// NewGenericFromFields returns the numeric fields of the parsed object f.
+func NewGenericFromFields(f *Fields) *Generic {
+	pstat := &Generic{
+		Name:   f.String("name"),
+		Origin: f.String("origin"),
+		Values: make(map[string]Number),
+	}
+	f.Range(func(key, value []byte) {
+		switch k := string(key); k {
+		case "name", "origin":
+		default:
+			pstat.addField(k, value)
+		}
+	})
+	return pstat
+}
+
+// addField records the numeric field k, or the numeric fields nested in it.
+func (g *Generic) addField(k string, raw []byte) {
+	var nested Fields
+	if nested.splitObject(raw) {
+		nested.Range(func(nk, nraw []byte) {
+			g.addField(k+"."+string(nk), nraw)
+		})
+		return
+	}
+	if n := parseNumber(raw); n.Valid() {
+		g.Values[k] = n
+	}
+}
+
+func (g *Generic) ToPoints() []*model.Point {
+	fields := make([]string, 0, len(g.Values))
+	for field := range g.Values {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	points := make([]*model.Point, 0, len(fields))
+	for _, field := range fields {
+		points = append(points, &model.Point{
+			Name:        "stat_value",
+			Type:        model.Gauge,
+			Value:       float64(g.Values[field]),
+			Description: "value of a field of an impstats object without a dedicated decoder",
+			Labels: []model.Label{
+				{Name: "name", Value: g.Name},
+				{Name: "origin", Value: g.Origin},
+				{Name: "field", Value: field},
+			},
+		})
+	}
+	return points
+}
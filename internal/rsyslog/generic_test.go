@@ -0,0 +1,66 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rsyslog
+
+import (
+	"testing"
+
+	"github.com/prometheus-community/rsyslog_exporter/internal/model"
+	th "github.com/prometheus-community/rsyslog_exporter/internal/testhelpers"
+)
+
+const genericLog = `{"name":"imfoo","origin":"imfoo","opened":3,"bytes.read":"1024","ratio":0.5,"state":"running","values":{"a":1}}`
+
+func TestNewGenericFromJSON(t *testing.T) {
+	pstat, err := NewGenericFromJSON([]byte(genericLog))
+	if err != nil {
+		t.Fatalf(th.ExpectedParseErrFmt, "generic", err)
+	}
+	th.AssertEqString(t, "name", "imfoo", pstat.Name)
+	th.AssertEqString(t, "origin", "imfoo", pstat.Origin)
+	if want, got := 4, len(pstat.Values); want != got {
+		t.Fatalf("want %d values, got %d: %v", want, got, pstat.Values)
+	}
+	th.AssertEqFloat(t, "bytes.read", 1024, float64(pstat.Values["bytes.read"]))
+	th.AssertEqFloat(t, "values.a", 1, float64(pstat.Values["values.a"]))
+	if _, ok := pstat.Values["state"]; ok {
+		t.Errorf("expected non-numeric field to be skipped")
+	}
+
+	if _, err := NewGenericFromJSON([]byte("not json")); err == nil {
+		t.Errorf("expected error for invalid JSON")
+	}
+}
+
+func TestGenericToPoints(t *testing.T) {
+	pstat, err := NewGenericFromJSON([]byte(genericLog))
+	if err != nil {
+		t.Fatalf(th.ExpectedParseErrFmt, "generic", err)
+	}
+	points := pstat.ToPoints()
+	wantFields := []string{"bytes.read", "opened", "ratio", "values.a"}
+	if want, got := len(wantFields), len(points); want != got {
+		t.Fatalf(th.ExpectedPointsFmt, want, got)
+	}
+	for i, field := range wantFields {
+		p := points[i]
+		th.AssertEqString(t, "point name", "stat_value", p.Name)
+		th.AssertEqString(t, "field", field, p.Label("field"))
+		th.AssertEqString(t, "name label", "imfoo", p.Label("name"))
+		th.AssertEqString(t, "origin label", "imfoo", p.Label("origin"))
+		if p.Type != model.Gauge {
+			t.Errorf("%s: want gauge, got %v", field, p.Type)
+		}
+	}
+}
@@ -14,7 +14,6 @@
 package rsyslog
 
 import (
-	"encoding/json"
 	"fmt"
 
 	"github.com/prometheus-community/rsyslog_exporter/internal/model"
@@ -23,48 +22,66 @@ import (
 // InputIMUDP represents rsyslog imudp input worker statistics.
 type InputIMUDP struct {
 	Name     string `json:"name"`
-	Recvmmsg int64  `json:"called.recvmmsg"`
-	Recvmsg  int64  `json:"called.recvmsg"`
-	Received int64  `json:"msgs.received"`
+	Recvmmsg Number `json:"called.recvmmsg"`
+	Recvmsg  Number `json:"called.recvmsg"`
+	Received Number `json:"msgs.received"`
 }
 
 func NewInputIMUDPFromJSON(b []byte) (*InputIMUDP, error) {
-	var pstat InputIMUDP
-	err := json.Unmarshal(b, &pstat)
-	if err != nil {
+	var f Fields
+	if err := f.Parse(b); err != nil {
 		return nil, fmt.Errorf("error decoding input stat `%v`: %w", string(b), err)
 	}
-	return &pstat, nil
+	return NewInputIMUDPFromFields(&f), nil
+}
+
+// NewInputIMUDPFromFields returns the imudp worker stats of the parsed object f.
+func NewInputIMUDPFromFields(f *Fields) *InputIMUDP {
+	return &InputIMUDP{
+		Name:     f.String("name"),
+		Recvmmsg: f.Number("called.recvmmsg"),
+		Recvmsg:  f.Number("called.recvmsg"),
+		Received: f.Number("msgs.received"),
+	}
 }
 
 func (i *InputIMUDP) ToPoints() []*model.Point {
 	points := make([]*model.Point, 3)
+	labels := i.labels()
 
 	points[0] = &model.Point{
 		Name:        "input_called_recvmmsg",
 		Type:        model.Counter,
-		Value:       i.Recvmmsg,
+		Value:       float64(i.Recvmmsg),
 		Description: "Number of recvmmsg called",
-		LabelName:   "worker",
-		LabelValue:  i.Name,
+		Labels:      labels,
 	}
 	points[1] = &model.Point{
 		Name:        "input_called_recvmsg",
 		Type:        model.Counter,
-		Value:       i.Recvmsg,
+		Value:       float64(i.Recvmsg),
 		Description: "Number of recvmsg called",
-		LabelName:   "worker",
-		LabelValue:  i.Name,
+		Labels:      labels,
 	}
 
 	points[2] = &model.Point{
 		Name:        "input_received",
 		Type:        model.Counter,
-		Value:       i.Received,
+		Value:       float64(i.Received),
 		Description: "messages received",
-		LabelName:   "worker",
-		LabelValue:  i.Name,
+		Labels:      labels,
 	}
 
-	return points
+	return validPoints(points)
+}
+
+// labels returns the labels of the series of the worker: its name, and the
+// module and worker thread it names, e.g. "imudp" and "w0".
+func (i *InputIMUDP) labels() []model.Label {
+	n := ParseName(i.Name)
+	return []model.Label{
+		{Name: "worker", Value: i.Name},
+		{Name: "module", Value: n.Module},
+		{Name: "worker_id", Value: n.Worker},
+	}
 }
@@ -32,9 +32,9 @@ func TestGetInputIMUDP(t *testing.T) {
 		t.Fatalf("parse input imudp stat failed: %v", err)
 	}
 	th.AssertEqString(t, "name", "test_input_imudp", pstat.Name)
-	th.AssertEqInt(t, "recvmmsg", 1000, pstat.Recvmmsg)
-	th.AssertEqInt(t, "recvmsg", 2000, pstat.Recvmsg)
-	th.AssertEqInt(t, "received", 500, pstat.Received)
+	th.AssertEqFloat(t, "recvmmsg", 1000, float64(pstat.Recvmmsg))
+	th.AssertEqFloat(t, "recvmsg", 2000, float64(pstat.Recvmsg))
+	th.AssertEqFloat(t, "received", 500, float64(pstat.Received))
 }
 
 func TestInputIMUDPtoPoints(t *testing.T) {
@@ -45,7 +45,7 @@ func TestInputIMUDPtoPoints(t *testing.T) {
 	points := pstat.ToPoints()
 	expected := []struct {
 		name  string
-		value int64
+		value float64
 	}{
 		{"input_called_recvmmsg", 1000},
 		{"input_called_recvmsg", 2000},
@@ -56,7 +56,7 @@ func TestInputIMUDPtoPoints(t *testing.T) {
 	}
 	for i, exp := range expected {
 		th.AssertEqString(t, exp.name+" name", exp.name, points[i].Name)
-		th.AssertEqInt(t, exp.name+" value", exp.value, points[i].Value)
-		th.AssertEqString(t, exp.name+" label", "test_input_imudp", points[i].LabelValue)
+		th.AssertEqFloat(t, exp.name+" value", exp.value, points[i].Value)
+		th.AssertEqString(t, exp.name+" label", "test_input_imudp", points[i].Label("worker"))
 	}
 }
@@ -14,7 +14,6 @@
 package rsyslog
 
 import (
-	"encoding/json"
 	"fmt"
 
 	"github.com/prometheus-community/rsyslog_exporter/internal/model"
@@ -23,16 +22,23 @@ import (
 // Input represents generic rsyslog input statistics.
 type Input struct {
 	Name      string `json:"name"`
-	Submitted int64  `json:"submitted"`
+	Submitted Number `json:"submitted"`
 }
 
 func NewInputFromJSON(b []byte) (*Input, error) {
-	var pstat Input
-	err := json.Unmarshal(b, &pstat)
-	if err != nil {
+	var f Fields
+	if err := f.Parse(b); err != nil {
 		return nil, fmt.Errorf("error decoding input stat `%v`: %w", string(b), err)
 	}
-	return &pstat, nil
+	return NewInputFromFields(&f), nil
+}
+
+// NewInputFromFields returns the input stats of the parsed object f.
+func NewInputFromFields(f *Fields) *Input {
+	return &Input{
+		Name:      f.String("name"),
+		Submitted: f.Number("submitted"),
+	}
 }
 
 func (i *Input) ToPoints() []*model.Point {
@@ -41,11 +47,10 @@ func (i *Input) ToPoints() []*model.Point {
 	points[0] = &model.Point{
 		Name:        "input_submitted",
 		Type:        model.Counter,
-		Value:       i.Submitted,
+		Value:       float64(i.Submitted),
 		Description: "messages submitted",
-		LabelName:   "input",
-		LabelValue:  i.Name,
+		Labels:      inputLabels(i.Name),
 	}
 
-	return points
+	return validPoints(points)
 }
@@ -38,8 +38,8 @@ func TestGetInput(t *testing.T) {
 		t.Errorf(th.ExpectedActualStringFmt, want, got)
 	}
 
-	if want, got := int64(1000), pstat.Submitted; want != got {
-		t.Errorf(th.ExpectedActualIntFmt, want, got)
+	if want, got := Number(1000), pstat.Submitted; want != got {
+		t.Errorf(th.ExpectedActualFloatFmt, want, got)
 	}
 }
 
@@ -56,11 +56,11 @@ func TestInputtoPoints(t *testing.T) {
 		t.Errorf(th.ExpectedActualStringFmt, want, got)
 	}
 
-	if want, got := int64(1000), point.Value; want != got {
-		t.Errorf(th.ExpectedActualIntFmt, want, got)
+	if want, got := float64(1000), point.Value; want != got {
+		t.Errorf(th.ExpectedActualFloatFmt, want, got)
 	}
 
-	if want, got := "test_input", point.LabelValue; want != got {
+	if want, got := "test_input", point.Label("input"); want != got {
 		t.Errorf(th.ExpectedActualStringFmt, want, got)
 	}
 }
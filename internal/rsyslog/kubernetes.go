@@ -14,43 +14,50 @@
 package rsyslog
 
 import (
-	"encoding/json"
 	"fmt"
-	"regexp"
 
 	"github.com/prometheus-community/rsyslog_exporter/internal/model"
 )
 
-var (
-	apiNameRegexp = regexp.MustCompile(`mmkubernetes\((\S+)\)`)
-)
-
 // Kubernetes represents rsyslog mmkubernetes module statistics.
 type Kubernetes struct {
 	Name                  string `json:"name"`
 	Url                   string
-	RecordSeen            int64 `json:"recordseen"`
-	NamespaceMetaSuccess  int64 `json:"namespacemetadatasuccess"`
-	NamespaceMetaNotFound int64 `json:"namespacemetadatanotfound"`
-	NamespaceMetaBusy     int64 `json:"namespacemetadatabusy"`
-	NamespaceMetaError    int64 `json:"namespacemetadataerror"`
-	PodMetaSuccess        int64 `json:"podmetadatasuccess"`
-	PodMetaNotFound       int64 `json:"podmetadatanotfound"`
-	PodMetaBusy           int64 `json:"podmetadatabusy"`
-	PodMetaError          int64 `json:"podmetadataerror"`
+	RecordSeen            Number `json:"recordseen"`
+	NamespaceMetaSuccess  Number `json:"namespacemetadatasuccess"`
+	NamespaceMetaNotFound Number `json:"namespacemetadatanotfound"`
+	NamespaceMetaBusy     Number `json:"namespacemetadatabusy"`
+	NamespaceMetaError    Number `json:"namespacemetadataerror"`
+	PodMetaSuccess        Number `json:"podmetadatasuccess"`
+	PodMetaNotFound       Number `json:"podmetadatanotfound"`
+	PodMetaBusy           Number `json:"podmetadatabusy"`
+	PodMetaError          Number `json:"podmetadataerror"`
 }
 
 func NewKubernetesFromJSON(b []byte) (*Kubernetes, error) {
-	var pstat Kubernetes
-	err := json.Unmarshal(b, &pstat)
-	if err != nil {
+	var f Fields
+	if err := f.Parse(b); err != nil {
 		return nil, fmt.Errorf("failed to decode kubernetes stat `%v`: %w", string(b), err)
 	}
-	matches := apiNameRegexp.FindSubmatch([]byte(pstat.Name))
-	if matches != nil {
-		pstat.Url = string(matches[1])
+	return NewKubernetesFromFields(&f), nil
+}
+
+// NewKubernetesFromFields returns the mmkubernetes stats of the parsed object f.
+func NewKubernetesFromFields(f *Fields) *Kubernetes {
+	pstat := &Kubernetes{
+		Name:                  f.String("name"),
+		RecordSeen:            f.Number("recordseen"),
+		NamespaceMetaSuccess:  f.Number("namespacemetadatasuccess"),
+		NamespaceMetaNotFound: f.Number("namespacemetadatanotfound"),
+		NamespaceMetaBusy:     f.Number("namespacemetadatabusy"),
+		NamespaceMetaError:    f.Number("namespacemetadataerror"),
+		PodMetaSuccess:        f.Number("podmetadatasuccess"),
+		PodMetaNotFound:       f.Number("podmetadatanotfound"),
+		PodMetaBusy:           f.Number("podmetadatabusy"),
+		PodMetaError:          f.Number("podmetadataerror"),
 	}
-	return &pstat, nil
+	pstat.Url = ParseName(pstat.Name).URL
+	return pstat
 }
 
 func (k *Kubernetes) ToPoints() []*model.Point {
@@ -59,83 +66,74 @@ func (k *Kubernetes) ToPoints() []*model.Point {
 	points[0] = &model.Point{
 		Name:        "kubernetes_namespace_metadata_success_total",
 		Type:        model.Counter,
-		Value:       k.NamespaceMetaSuccess,
+		Value:       float64(k.NamespaceMetaSuccess),
 		Description: "successful fetches of namespace metadata",
-		LabelName:   "url",
-		LabelValue:  k.Url,
+		Labels:      []model.Label{{Name: "url", Value: k.Url}},
 	}
 
 	points[1] = &model.Point{
 		Name:        "kubernetes_namespace_metadata_notfound_total",
 		Type:        model.Counter,
-		Value:       k.NamespaceMetaNotFound,
+		Value:       float64(k.NamespaceMetaNotFound),
 		Description: "notfound fetches of namespace metadata",
-		LabelName:   "url",
-		LabelValue:  k.Url,
+		Labels:      []model.Label{{Name: "url", Value: k.Url}},
 	}
 
 	points[2] = &model.Point{
 		Name:        "kubernetes_namespace_metadata_busy_total",
 		Type:        model.Counter,
-		Value:       k.NamespaceMetaBusy,
+		Value:       float64(k.NamespaceMetaBusy),
 		Description: "busy fetches of namespace metadata",
-		LabelName:   "url",
-		LabelValue:  k.Url,
+		Labels:      []model.Label{{Name: "url", Value: k.Url}},
 	}
 
 	points[3] = &model.Point{
 		Name:        "kubernetes_namespace_metadata_error_total",
 		Type:        model.Counter,
-		Value:       k.NamespaceMetaError,
+		Value:       float64(k.NamespaceMetaError),
 		Description: "error fetches of namespace metadata",
-		LabelName:   "url",
-		LabelValue:  k.Url,
+		Labels:      []model.Label{{Name: "url", Value: k.Url}},
 	}
 
 	points[4] = &model.Point{
 		Name:        "kubernetes_pod_metadata_success_total",
 		Type:        model.Counter,
-		Value:       k.PodMetaSuccess,
+		Value:       float64(k.PodMetaSuccess),
 		Description: "successful fetches of pod metadata",
-		LabelName:   "url",
-		LabelValue:  k.Url,
+		Labels:      []model.Label{{Name: "url", Value: k.Url}},
 	}
 
 	points[5] = &model.Point{
 		Name:        "kubernetes_pod_metadata_notfound_total",
 		Type:        model.Counter,
-		Value:       k.PodMetaNotFound,
+		Value:       float64(k.PodMetaNotFound),
 		Description: "notfound fetches of pod metadata",
-		LabelName:   "url",
-		LabelValue:  k.Url,
+		Labels:      []model.Label{{Name: "url", Value: k.Url}},
 	}
 
 	points[6] = &model.Point{
 		Name:        "kubernetes_pod_metadata_busy_total",
 		Type:        model.Counter,
-		Value:       k.PodMetaBusy,
+		Value:       float64(k.PodMetaBusy),
 		Description: "busy fetches of pod metadata",
-		LabelName:   "url",
-		LabelValue:  k.Url,
+		Labels:      []model.Label{{Name: "url", Value: k.Url}},
 	}
 
 	points[7] = &model.Point{
 		Name:        "kubernetes_pod_metadata_error_total",
 		Type:        model.Counter,
-		Value:       k.PodMetaError,
+		Value:       float64(k.PodMetaError),
 		Description: "error fetches of pod metadata",
-		LabelName:   "url",
-		LabelValue:  k.Url,
+		Labels:      []model.Label{{Name: "url", Value: k.Url}},
 	}
 
 	points[8] = &model.Point{
 		Name:        "kubernetes_record_seen_total",
 		Type:        model.Counter,
-		Value:       k.RecordSeen,
+		Value:       float64(k.RecordSeen),
 		Description: "records fetched from the api",
-		LabelName:   "url",
-		LabelValue:  k.Url,
+		Labels:      []model.Label{{Name: "url", Value: k.Url}},
 	}
 
-	return points
+	return validPoints(points)
 }
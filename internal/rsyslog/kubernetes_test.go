@@ -38,8 +38,8 @@ func TestNewKubernetesFromJSON(t *testing.T) {
 	// Numeric field expectations.
 	numExpectations := []struct {
 		ctx  string
-		want int64
-		got  int64
+		want Number
+		got  Number
 	}{
 		{"record_seen", 477943, pstat.RecordSeen},
 		{"ns_meta_success", 7, pstat.NamespaceMetaSuccess},
@@ -52,7 +52,7 @@ func TestNewKubernetesFromJSON(t *testing.T) {
 		{"pod_meta_error", 0, pstat.PodMetaError},
 	}
 	for _, ne := range numExpectations {
-		th.AssertEqInt(t, ne.ctx, ne.want, ne.got)
+		th.AssertEqFloat(t, ne.ctx, float64(ne.want), float64(ne.got))
 	}
 }
 
@@ -80,6 +80,6 @@ func TestKubernetesToPoints(t *testing.T) {
 		if points[i].Name != name {
 			t.Errorf(th.ExpectedActualStringFmt, name, points[i].Name)
 		}
-		th.AssertEqString(t, "label url", "https://host.domain.tld:6443", points[i].LabelValue)
+		th.AssertEqString(t, "label url", "https://host.domain.tld:6443", points[i].Label("url"))
 	}
 }
@@ -0,0 +1,132 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rsyslog
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/prometheus-community/rsyslog_exporter/internal/model"
+)
+
+// Name is the structure rsyslog encodes in the names of impstats objects,
+// e.g. "imudp(w0)", "imtcp(514)", "action-3-builtin:omfile" or
+// "action 2 queue[DA]". Parts a name does not carry are empty.
+type Name struct {
+	// Base is the name without the "[DA]" suffix of disk-assisted queues.
+	Base string
+	// Module is the rsyslog module, e.g. "imudp" or "omfile".
+	Module string
+	// Address and Port are the listener of an input, e.g. "*" and "514".
+	Address string
+	Port    string
+	// Worker is the worker thread of an input, e.g. "w0".
+	Worker string
+	// Action is the index of an unnamed action or of its queue, e.g. "3".
+	Action string
+	// Owner is the action or ruleset a queue belongs to, e.g. "fwd" of
+	// "fwd queue" or "action 2" of "action 2 queue". The main queue has
+	// none.
+	Owner string
+	// URL is the Kubernetes API server of mmkubernetes.
+	URL string
+	// Cache is the name of a dynafile cache.
+	Cache string
+	// DA reports the disk-assisted part of a queue.
+	DA bool
+}
+
+var (
+	// moduleArgRegexp matches "module(argument)".
+	moduleArgRegexp = regexp.MustCompile(`^([a-z][a-z0-9_]*)\((.*)\)$`)
+	// actionRegexp matches the default action names, e.g.
+	// "action-3-builtin:omfile" or "action 2" as in the queue "action 2 queue".
+	actionRegexp = regexp.MustCompile(`^action[- ](\d+)(?:-(?:builtin:)?(.+))?$`)
+	// moduleRegexp matches plain module names such as "imuxsock".
+	moduleRegexp = regexp.MustCompile(`^[iom]m[a-z0-9_]+$`)
+	workerRegexp = regexp.MustCompile(`^w\d+$`)
+	portRegexp   = regexp.MustCompile(`^\d+$`)
+)
+
+// daSuffix marks the disk-assisted part of a queue.
+const daSuffix = "[DA]"
+
+// queueSuffix ends the names of action and ruleset queues.
+const queueSuffix = " queue"
+
+// dynafileCachePrefix starts the names of omfile dynafile caches.
+const dynafileCachePrefix = "dynafile cache "
+
+// ParseName splits the impstats object name into its parts.
+func ParseName(name string) Name {
+	n := Name{Base: name}
+	if base, ok := strings.CutSuffix(name, daSuffix); ok {
+		n.Base, n.DA = base, true
+	}
+	if cache, ok := strings.CutPrefix(n.Base, dynafileCachePrefix); ok {
+		n.Module, n.Cache = "omfile", cache
+		return n
+	}
+	// a queue is named after its owner, which is named freely, so only the
+	// index of an unnamed action is taken from it
+	if owner, ok := strings.CutSuffix(n.Base, queueSuffix); ok {
+		n.Owner = owner
+		if m := actionRegexp.FindStringSubmatch(owner); m != nil {
+			n.Action = m[1]
+		}
+		return n
+	}
+	if m := actionRegexp.FindStringSubmatch(n.Base); m != nil {
+		n.Action, n.Module = m[1], m[2]
+		return n
+	}
+	if m := moduleArgRegexp.FindStringSubmatch(n.Base); m != nil {
+		n.Module = m[1]
+		n.parseArg(m[2])
+		return n
+	}
+	if moduleRegexp.MatchString(n.Base) {
+		n.Module = n.Base
+	}
+	return n
+}
+
+// parseArg interprets the argument of a "module(argument)" name.
+func (n *Name) parseArg(arg string) {
+	switch {
+	case n.Module == "mmkubernetes":
+		n.URL = arg
+	case workerRegexp.MatchString(arg):
+		n.Worker = arg
+	case portRegexp.MatchString(arg):
+		n.Port = arg
+	default:
+		i := strings.LastIndexByte(arg, ':')
+		if i >= 0 && portRegexp.MatchString(arg[i+1:]) {
+			n.Address = strings.Trim(arg[:i], "[]")
+			n.Port = arg[i+1:]
+		}
+	}
+}
+
+// inputLabels returns the labels of the series of the input name.
+func inputLabels(name string) []model.Label {
+	n := ParseName(name)
+	return []model.Label{
+		{Name: "input", Value: name},
+		{Name: "module", Value: n.Module},
+		{Name: "address", Value: n.Address},
+		{Name: "port", Value: n.Port},
+	}
+}
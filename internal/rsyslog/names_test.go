@@ -0,0 +1,49 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rsyslog
+
+import (
+	"testing"
+)
+
+func TestParseName(t *testing.T) {
+	tests := []struct {
+		name string
+		want Name
+	}{
+		{"imudp(w0)", Name{Base: "imudp(w0)", Module: "imudp", Worker: "w0"}},
+		{"imudp(*:514)", Name{Base: "imudp(*:514)", Module: "imudp", Address: "*", Port: "514"}},
+		{"imudp([::1]:514)", Name{Base: "imudp([::1]:514)", Module: "imudp", Address: "::1", Port: "514"}},
+		{"imtcp(514)", Name{Base: "imtcp(514)", Module: "imtcp", Port: "514"}},
+		{"imptcp(/var/run/log.sock)", Name{Base: "imptcp(/var/run/log.sock)", Module: "imptcp"}},
+		{"imuxsock", Name{Base: "imuxsock", Module: "imuxsock"}},
+		{"mmkubernetes(https://host.domain.tld:6443)", Name{Base: "mmkubernetes(https://host.domain.tld:6443)", Module: "mmkubernetes", URL: "https://host.domain.tld:6443"}},
+		{"dynafile cache cluster", Name{Base: "dynafile cache cluster", Module: "omfile", Cache: "cluster"}},
+		{"action-3-builtin:omfile", Name{Base: "action-3-builtin:omfile", Module: "omfile", Action: "3"}},
+		{"action-7-omkafka", Name{Base: "action-7-omkafka", Module: "omkafka", Action: "7"}},
+		{"action 2 queue", Name{Base: "action 2 queue", Action: "2", Owner: "action 2"}},
+		{"action 2 queue[DA]", Name{Base: "action 2 queue", Action: "2", Owner: "action 2", DA: true}},
+		{"main Q", Name{Base: "main Q"}},
+		{"main Q[DA]", Name{Base: "main Q", DA: true}},
+		{"fwd", Name{Base: "fwd"}},
+		{"fwd queue", Name{Base: "fwd queue", Owner: "fwd"}},
+		{"myruleset queue", Name{Base: "myruleset queue", Owner: "myruleset"}},
+		{"omfwd queue", Name{Base: "omfwd queue", Owner: "omfwd"}},
+	}
+	for _, tt := range tests {
+		if got := ParseName(tt.name); got != tt.want {
+			t.Errorf("ParseName(%q) = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
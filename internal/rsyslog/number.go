@@ -0,0 +1,71 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rsyslog
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+
+	"github.com/prometheus-community/rsyslog_exporter/internal/model"
+)
+
+// Number is a stats value. It decodes from JSON numbers of any size or
+// precision, including unsigned 64-bit counters and fractions, and from
+// numbers encoded as JSON strings. A malformed value does not fail decoding
+// of the object it belongs to but decodes as NaN, and its point is dropped.
+type Number float64
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (n *Number) UnmarshalJSON(b []byte) error {
+	*n = parseNumber(b)
+	return nil
+}
+
+// parseNumber decodes the raw JSON value b. A missing value or null is 0,
+// anything but a finite number NaN.
+func parseNumber(b []byte) Number {
+	if b == nil || bytes.Equal(b, []byte("null")) {
+		return 0
+	}
+	if len(b) >= 2 && b[0] == '"' {
+		if bytes.IndexByte(b, '\\') < 0 {
+			b = b[1 : len(b)-1]
+		} else {
+			b = []byte(stringValue(b))
+		}
+		b = bytes.TrimSpace(b)
+	}
+	v, err := strconv.ParseFloat(string(b), 64)
+	if err != nil || math.IsInf(v, 0) || math.IsNaN(v) {
+		return Number(math.NaN())
+	}
+	return Number(v)
+}
+
+// Valid reports whether n was decoded from a well-formed value.
+func (n Number) Valid() bool {
+	return !math.IsNaN(float64(n))
+}
+
+// validPoints drops points whose value was malformed.
+func validPoints(points []*model.Point) []*model.Point {
+	valid := points[:0]
+	for _, p := range points {
+		if !math.IsNaN(p.Value) {
+			valid = append(valid, p)
+		}
+	}
+	return valid
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rsyslog
+
+import (
+	"encoding/json"
+	"testing"
+
+	th "github.com/prometheus-community/rsyslog_exporter/internal/testhelpers"
+)
+
+func TestNumberUnmarshal(t *testing.T) {
+	tests := map[string]float64{
+		`42`:                   42,
+		`18446744073709551615`: 18446744073709551615,
+		`0.25`:                 0.25,
+		`1e3`:                  1000,
+		`"17"`:                 17,
+		`" 2.5 "`:              2.5,
+		`null`:                 0,
+	}
+	for in, want := range tests {
+		var n Number
+		if err := json.Unmarshal([]byte(in), &n); err != nil {
+			t.Fatalf("Unmarshal(%s) failed: %v", in, err)
+		}
+		if !n.Valid() {
+			t.Errorf("Unmarshal(%s): expected valid number", in)
+		}
+		th.AssertEqFloat(t, in, want, float64(n))
+	}
+
+	for _, in := range []string{`"n/a"`, `true`, `{}`, `[1]`, `1e400`, `"NaN"`, `"Inf"`} {
+		var n Number
+		if err := json.Unmarshal([]byte(in), &n); err != nil {
+			t.Fatalf("Unmarshal(%s) failed: %v", in, err)
+		}
+		if n.Valid() {
+			t.Errorf("Unmarshal(%s): expected malformed number, got %v", in, float64(n))
+		}
+	}
+}
+
+func TestMalformedFieldKeepsObject(t *testing.T) {
+	pstat, err := NewQueueFromJSON([]byte(`{"name":"main Q","origin":"core.queue","size":"10","enqueued":18446744073709551615,"full":"lots","discarded.full":0.5,"discarded.nf":0,"maxqsize":60}`))
+	if err != nil {
+		t.Fatalf(th.ExpectedParseErrFmt, "queue", err)
+	}
+	points := pstat.ToPoints()
+	if want, got := 5, len(points); want != got {
+		t.Fatalf(th.ExpectedPointsFmt, want, got)
+	}
+	values := map[string]float64{}
+	for _, p := range points {
+		values[p.Name] = p.Value
+	}
+	if _, ok := values["queue_full"]; ok {
+		t.Errorf("expected malformed queue_full to be dropped")
+	}
+	th.AssertEqFloat(t, "size", 10, values["queue_size"])
+	th.AssertEqFloat(t, "enqueued", 18446744073709551615, values["queue_enqueued"])
+	th.AssertEqFloat(t, "discarded full", 0.5, values["queue_discarded_full"])
+}
@@ -14,7 +14,6 @@
 package rsyslog
 
 import (
-	"encoding/json"
 	"fmt"
 
 	"github.com/prometheus-community/rsyslog_exporter/internal/model"
@@ -24,27 +23,27 @@ import (
 type Omkafka struct {
 	Name                     string `json:"name"`
 	Origin                   string `json:"origin"`
-	Submitted                int64  `json:"submitted"`
-	MaxOutQSize              int64  `json:"maxoutqsize"`
-	Failures                 int64  `json:"failures"`
-	TopicDynacacheSkipped    int64  `json:"topicdynacache.skipped"`
-	TopicDynacacheMiss       int64  `json:"topicdynacache.miss"`
-	TopicDynacacheEvicted    int64  `json:"topicdynacache.evicted"`
-	Acked                    int64  `json:"acked"`
-	FailuresMsgTooLarge      int64  `json:"failures_msg_too_large"`
-	FailuresUnknownTopic     int64  `json:"failures_unknown_topic"`
-	FailuresQueueFull        int64  `json:"failures_queue_full"`
-	FailuresUnknownPartition int64  `json:"failures_unknown_partition"`
-	FailuresOther            int64  `json:"failures_other"`
-	ErrorsTimedOut           int64  `json:"errors_timed_out"`
-	ErrorsTransport          int64  `json:"errors_transport"`
-	ErrorsBrokerDown         int64  `json:"errors_broker_down"`
-	ErrorsAuth               int64  `json:"errors_auth"`
-	ErrorsSSL                int64  `json:"errors_ssl"`
-	ErrorsOther              int64  `json:"errors_other"`
-	RttAvgUsec               int64  `json:"rtt_avg_usec"`
-	ThrottleAvgMsec          int64  `json:"throttle_avg_msec"`
-	IntLatencyAvgUsec        int64  `json:"int_latency_avg_usec"`
+	Submitted                Number `json:"submitted"`
+	MaxOutQSize              Number `json:"maxoutqsize"`
+	Failures                 Number `json:"failures"`
+	TopicDynacacheSkipped    Number `json:"topicdynacache.skipped"`
+	TopicDynacacheMiss       Number `json:"topicdynacache.miss"`
+	TopicDynacacheEvicted    Number `json:"topicdynacache.evicted"`
+	Acked                    Number `json:"acked"`
+	FailuresMsgTooLarge      Number `json:"failures_msg_too_large"`
+	FailuresUnknownTopic     Number `json:"failures_unknown_topic"`
+	FailuresQueueFull        Number `json:"failures_queue_full"`
+	FailuresUnknownPartition Number `json:"failures_unknown_partition"`
+	FailuresOther            Number `json:"failures_other"`
+	ErrorsTimedOut           Number `json:"errors_timed_out"`
+	ErrorsTransport          Number `json:"errors_transport"`
+	ErrorsBrokerDown         Number `json:"errors_broker_down"`
+	ErrorsAuth               Number `json:"errors_auth"`
+	ErrorsSSL                Number `json:"errors_ssl"`
+	ErrorsOther              Number `json:"errors_other"`
+	RttAvgUsec               Number `json:"rtt_avg_usec"`
+	ThrottleAvgMsec          Number `json:"throttle_avg_msec"`
+	IntLatencyAvgUsec        Number `json:"int_latency_avg_usec"`
 }
 
 const (
@@ -55,12 +54,40 @@ const (
 )
 
 func NewOmkafkaFromJSON(b []byte) (*Omkafka, error) {
-	var pstat Omkafka
-	err := json.Unmarshal(b, &pstat)
-	if err != nil {
+	var f Fields
+	if err := f.Parse(b); err != nil {
 		return nil, fmt.Errorf("failed to decode omkafka stat `%v`: %w", string(b), err)
 	}
-	return &pstat, nil
+	return NewOmkafkaFromFields(&f), nil
+}
+
+// NewOmkafkaFromFields returns the omkafka stats of the parsed object f.
+func NewOmkafkaFromFields(f *Fields) *Omkafka {
+	return &Omkafka{
+		Name:                     f.String("name"),
+		Origin:                   f.String("origin"),
+		Submitted:                f.Number("submitted"),
+		MaxOutQSize:              f.Number("maxoutqsize"),
+		Failures:                 f.Number("failures"),
+		TopicDynacacheSkipped:    f.Number("topicdynacache.skipped"),
+		TopicDynacacheMiss:       f.Number("topicdynacache.miss"),
+		TopicDynacacheEvicted:    f.Number("topicdynacache.evicted"),
+		Acked:                    f.Number("acked"),
+		FailuresMsgTooLarge:      f.Number("failures_msg_too_large"),
+		FailuresUnknownTopic:     f.Number("failures_unknown_topic"),
+		FailuresQueueFull:        f.Number("failures_queue_full"),
+		FailuresUnknownPartition: f.Number("failures_unknown_partition"),
+		FailuresOther:            f.Number("failures_other"),
+		ErrorsTimedOut:           f.Number("errors_timed_out"),
+		ErrorsTransport:          f.Number("errors_transport"),
+		ErrorsBrokerDown:         f.Number("errors_broker_down"),
+		ErrorsAuth:               f.Number("errors_auth"),
+		ErrorsSSL:                f.Number("errors_ssl"),
+		ErrorsOther:              f.Number("errors_other"),
+		RttAvgUsec:               f.Number("rtt_avg_usec"),
+		ThrottleAvgMsec:          f.Number("throttle_avg_msec"),
+		IntLatencyAvgUsec:        f.Number("int_latency_avg_usec"),
+	}
 }
 
 func (o *Omkafka) ToPoints() []*model.Point {
@@ -72,190 +99,172 @@ func (o *Omkafka) ToPoints() []*model.Point {
 	points[0] = &model.Point{
 		Name:        "input_submitted",
 		Type:        model.Counter,
-		Value:       o.Submitted,
+		Value:       float64(o.Submitted),
 		Description: "messages submitted",
-		LabelName:   "input",
-		LabelValue:  o.Name,
+		Labels:      inputLabels(o.Name),
 	}
 	points[1] = &model.Point{
 		Name:        "omkafka_messages",
 		Type:        model.Counter,
-		Value:       o.Submitted,
+		Value:       float64(o.Submitted),
 		Description: messagesDescription,
-		LabelName:   "type",
-		LabelValue:  "submitted",
+		Labels:      []model.Label{{Name: "type", Value: "submitted"}},
 	}
 	points[2] = &model.Point{
 		Name:        "omkafka_maxoutqsize",
 		Type:        model.Counter,
-		Value:       o.MaxOutQSize,
+		Value:       float64(o.MaxOutQSize),
 		Description: "high water mark of output queue size",
 	}
 
 	points[3] = &model.Point{
 		Name:        "omkafka_messages",
 		Type:        model.Counter,
-		Value:       o.Failures,
+		Value:       float64(o.Failures),
 		Description: messagesDescription,
-		LabelName:   "type",
-		LabelValue:  "failures",
+		Labels:      []model.Label{{Name: "type", Value: "failures"}},
 	}
 
 	points[4] = &model.Point{
 		Name:        "omkafka_topicdynacache",
 		Type:        model.Counter,
-		Value:       o.TopicDynacacheSkipped,
+		Value:       float64(o.TopicDynacacheSkipped),
 		Description: topicDynaCacheDescription,
-		LabelName:   "type",
-		LabelValue:  "skipped",
+		Labels:      []model.Label{{Name: "type", Value: "skipped"}},
 	}
 
 	points[5] = &model.Point{
 		Name:        "omkafka_topicdynacache",
 		Type:        model.Counter,
-		Value:       o.TopicDynacacheMiss,
+		Value:       float64(o.TopicDynacacheMiss),
 		Description: topicDynaCacheDescription,
-		LabelName:   "type",
-		LabelValue:  "miss",
+		Labels:      []model.Label{{Name: "type", Value: "miss"}},
 	}
 
 	points[6] = &model.Point{
 		Name:        "omkafka_topicdynacache",
 		Type:        model.Counter,
-		Value:       o.TopicDynacacheEvicted,
+		Value:       float64(o.TopicDynacacheEvicted),
 		Description: topicDynaCacheDescription,
-		LabelName:   "type",
-		LabelValue:  "evicted",
+		Labels:      []model.Label{{Name: "type", Value: "evicted"}},
 	}
 
 	points[7] = &model.Point{
 		Name:        "omkafka_messages",
 		Type:        model.Counter,
-		Value:       o.Acked,
+		Value:       float64(o.Acked),
 		Description: messagesDescription,
-		LabelName:   "type",
-		LabelValue:  "acked",
+		Labels:      []model.Label{{Name: "type", Value: "acked"}},
 	}
 
 	points[8] = &model.Point{
 		Name:        "omkafka_failures",
 		Type:        model.Counter,
-		Value:       o.FailuresMsgTooLarge,
+		Value:       float64(o.FailuresMsgTooLarge),
 		Description: failuresDescription,
-		LabelName:   "type",
-		LabelValue:  "msg_too_large",
+		Labels:      []model.Label{{Name: "type", Value: "msg_too_large"}},
 	}
 
 	points[9] = &model.Point{
 		Name:        "omkafka_failures",
 		Type:        model.Counter,
-		Value:       o.FailuresUnknownTopic,
+		Value:       float64(o.FailuresUnknownTopic),
 		Description: failuresDescription,
-		LabelName:   "type",
-		LabelValue:  "unknown_topic",
+		Labels:      []model.Label{{Name: "type", Value: "unknown_topic"}},
 	}
 
 	points[10] = &model.Point{
 		Name:        "omkafka_failures",
 		Type:        model.Counter,
-		Value:       o.FailuresQueueFull,
+		Value:       float64(o.FailuresQueueFull),
 		Description: failuresDescription,
-		LabelName:   "type",
-		LabelValue:  "queue_full",
+		Labels:      []model.Label{{Name: "type", Value: "queue_full"}},
 	}
 
 	points[11] = &model.Point{
 		Name:        "omkafka_failures",
 		Type:        model.Counter,
-		Value:       o.FailuresUnknownPartition,
+		Value:       float64(o.FailuresUnknownPartition),
 		Description: failuresDescription,
-		LabelName:   "type",
-		LabelValue:  "unknown_partition",
+		Labels:      []model.Label{{Name: "type", Value: "unknown_partition"}},
 	}
 
 	points[12] = &model.Point{
 		Name:        "omkafka_failures",
 		Type:        model.Counter,
-		Value:       o.FailuresOther,
+		Value:       float64(o.FailuresOther),
 		Description: failuresDescription,
-		LabelName:   "type",
-		LabelValue:  "other",
+		Labels:      []model.Label{{Name: "type", Value: "other"}},
 	}
 
 	points[13] = &model.Point{
 		Name:        "omkafka_errors",
 		Type:        model.Counter,
-		Value:       o.ErrorsTimedOut,
+		Value:       float64(o.ErrorsTimedOut),
 		Description: errorsDescription,
-		LabelName:   "type",
-		LabelValue:  "timed_out",
+		Labels:      []model.Label{{Name: "type", Value: "timed_out"}},
 	}
 
 	points[14] = &model.Point{
 		Name:        "omkafka_errors",
 		Type:        model.Counter,
-		Value:       o.ErrorsTransport,
+		Value:       float64(o.ErrorsTransport),
 		Description: errorsDescription,
-		LabelName:   "type",
-		LabelValue:  "transport",
+		Labels:      []model.Label{{Name: "type", Value: "transport"}},
 	}
 
 	points[15] = &model.Point{
 		Name:        "omkafka_errors",
 		Type:        model.Counter,
-		Value:       o.ErrorsBrokerDown,
+		Value:       float64(o.ErrorsBrokerDown),
 		Description: errorsDescription,
-		LabelName:   "type",
-		LabelValue:  "broker_down",
+		Labels:      []model.Label{{Name: "type", Value: "broker_down"}},
 	}
 
 	points[16] = &model.Point{
 		Name:        "omkafka_errors",
 		Type:        model.Counter,
-		Value:       o.ErrorsAuth,
+		Value:       float64(o.ErrorsAuth),
 		Description: errorsDescription,
-		LabelName:   "type",
-		LabelValue:  "auth",
+		Labels:      []model.Label{{Name: "type", Value: "auth"}},
 	}
 
 	points[17] = &model.Point{
 		Name:        "omkafka_errors",
 		Type:        model.Counter,
-		Value:       o.ErrorsSSL,
+		Value:       float64(o.ErrorsSSL),
 		Description: errorsDescription,
-		LabelName:   "type",
-		LabelValue:  "ssl",
+		Labels:      []model.Label{{Name: "type", Value: "ssl"}},
 	}
 
 	points[18] = &model.Point{
 		Name:        "omkafka_errors",
 		Type:        model.Counter,
-		Value:       o.ErrorsOther,
+		Value:       float64(o.ErrorsOther),
 		Description: errorsDescription,
-		LabelName:   "type",
-		LabelValue:  "other",
+		Labels:      []model.Label{{Name: "type", Value: "other"}},
 	}
 
 	points[19] = &model.Point{
 		Name:        "omkafka_rtt_avg_usec_avg",
 		Type:        model.Gauge,
-		Value:       o.RttAvgUsec,
+		Value:       float64(o.RttAvgUsec),
 		Description: "broker round trip time in microseconds averaged over all brokers. It is based on the statistics callback window specified through statistics.interval.ms parameter to librdkafka. Average excludes brokers with less than 100 microseconds rtt",
 	}
 
 	points[20] = &model.Point{
 		Name:        "omkafka_throttle_avg_msec_avg",
 		Type:        model.Gauge,
-		Value:       o.ThrottleAvgMsec,
+		Value:       float64(o.ThrottleAvgMsec),
 		Description: "broker throttling time in milliseconds averaged over all brokers. This is also a part of window statistics delivered by librdkakfka. Average excludes brokers with zero throttling time",
 	}
 
 	points[21] = &model.Point{
 		Name:        "omkafka_int_latency_avg_usec_avg",
 		Type:        model.Gauge,
-		Value:       o.IntLatencyAvgUsec,
+		Value:       float64(o.IntLatencyAvgUsec),
 		Description: "internal librdkafka producer queue latency in microseconds averaged over all brokers. This is also part of window statistics and average excludes brokers with zero internal latency",
 	}
 
-	return points
+	return validPoints(points)
 }
@@ -47,16 +47,16 @@ func TestOmkafkaToPoints(t *testing.T) {
 
 	testCases := []*model.Point{
 		{
-			Name:       "input_submitted",
-			Type:       model.Counter,
-			Value:      59,
-			LabelValue: "omkafka",
+			Name:   "input_submitted",
+			Type:   model.Counter,
+			Value:  59,
+			Labels: []model.Label{{Name: "input", Value: "omkafka"}, {Name: "module", Value: "omkafka"}, {Name: "address"}, {Name: "port"}},
 		},
 		{
-			Name:       "omkafka_messages",
-			Type:       model.Counter,
-			Value:      59,
-			LabelValue: "submitted",
+			Name:   "omkafka_messages",
+			Type:   model.Counter,
+			Value:  59,
+			Labels: []model.Label{{Name: "type", Value: "submitted"}},
 		},
 		{
 			Name:  "omkafka_maxoutqsize",
@@ -64,101 +64,101 @@ func TestOmkafkaToPoints(t *testing.T) {
 			Value: 9,
 		},
 		{
-			Name:       "omkafka_messages",
-			Type:       model.Counter,
-			Value:      0,
-			LabelValue: "failures",
+			Name:   "omkafka_messages",
+			Type:   model.Counter,
+			Value:  0,
+			Labels: []model.Label{{Name: "type", Value: "failures"}},
 		},
 		{
-			Name:       "omkafka_topicdynacache",
-			Type:       model.Counter,
-			Value:      57,
-			LabelValue: "skipped",
+			Name:   "omkafka_topicdynacache",
+			Type:   model.Counter,
+			Value:  57,
+			Labels: []model.Label{{Name: "type", Value: "skipped"}},
 		},
 		{
-			Name:       "omkafka_topicdynacache",
-			Type:       model.Counter,
-			Value:      2,
-			LabelValue: "miss",
+			Name:   "omkafka_topicdynacache",
+			Type:   model.Counter,
+			Value:  2,
+			Labels: []model.Label{{Name: "type", Value: "miss"}},
 		},
 		{
-			Name:       "omkafka_topicdynacache",
-			Type:       model.Counter,
-			Value:      0,
-			LabelValue: "evicted",
+			Name:   "omkafka_topicdynacache",
+			Type:   model.Counter,
+			Value:  0,
+			Labels: []model.Label{{Name: "type", Value: "evicted"}},
 		},
 		{
-			Name:       "omkafka_messages",
-			Type:       model.Counter,
-			Value:      55,
-			LabelValue: "acked",
+			Name:   "omkafka_messages",
+			Type:   model.Counter,
+			Value:  55,
+			Labels: []model.Label{{Name: "type", Value: "acked"}},
 		},
 		{
-			Name:       "omkafka_failures",
-			Type:       model.Counter,
-			Value:      0,
-			LabelValue: "msg_too_large",
+			Name:   "omkafka_failures",
+			Type:   model.Counter,
+			Value:  0,
+			Labels: []model.Label{{Name: "type", Value: "msg_too_large"}},
 		},
 
 		{
-			Name:       "omkafka_failures",
-			Type:       model.Counter,
-			Value:      0,
-			LabelValue: "unknown_topic",
+			Name:   "omkafka_failures",
+			Type:   model.Counter,
+			Value:  0,
+			Labels: []model.Label{{Name: "type", Value: "unknown_topic"}},
 		},
 		{
-			Name:       "omkafka_failures",
-			Type:       model.Counter,
-			Value:      0,
-			LabelValue: "queue_full",
+			Name:   "omkafka_failures",
+			Type:   model.Counter,
+			Value:  0,
+			Labels: []model.Label{{Name: "type", Value: "queue_full"}},
 		},
 		{
-			Name:       "omkafka_failures",
-			Type:       model.Counter,
-			Value:      0,
-			LabelValue: "unknown_partition",
+			Name:   "omkafka_failures",
+			Type:   model.Counter,
+			Value:  0,
+			Labels: []model.Label{{Name: "type", Value: "unknown_partition"}},
 		},
 		{
-			Name:       "omkafka_failures",
-			Type:       model.Counter,
-			Value:      0,
-			LabelValue: "other",
+			Name:   "omkafka_failures",
+			Type:   model.Counter,
+			Value:  0,
+			Labels: []model.Label{{Name: "type", Value: "other"}},
 		},
 		{
-			Name:       "omkafka_errors",
-			Type:       model.Counter,
-			Value:      0,
-			LabelValue: "timed_out",
+			Name:   "omkafka_errors",
+			Type:   model.Counter,
+			Value:  0,
+			Labels: []model.Label{{Name: "type", Value: "timed_out"}},
 		},
 		{
-			Name:       "omkafka_errors",
-			Type:       model.Counter,
-			Value:      0,
-			LabelValue: "transport",
+			Name:   "omkafka_errors",
+			Type:   model.Counter,
+			Value:  0,
+			Labels: []model.Label{{Name: "type", Value: "transport"}},
 		},
 		{
-			Name:       "omkafka_errors",
-			Type:       model.Counter,
-			Value:      0,
-			LabelValue: "broker_down",
+			Name:   "omkafka_errors",
+			Type:   model.Counter,
+			Value:  0,
+			Labels: []model.Label{{Name: "type", Value: "broker_down"}},
 		},
 		{
-			Name:       "omkafka_errors",
-			Type:       model.Counter,
-			Value:      0,
-			LabelValue: "auth",
+			Name:   "omkafka_errors",
+			Type:   model.Counter,
+			Value:  0,
+			Labels: []model.Label{{Name: "type", Value: "auth"}},
 		},
 		{
-			Name:       "omkafka_errors",
-			Type:       model.Counter,
-			Value:      0,
-			LabelValue: "ssl",
+			Name:   "omkafka_errors",
+			Type:   model.Counter,
+			Value:  0,
+			Labels: []model.Label{{Name: "type", Value: "ssl"}},
 		},
 		{
-			Name:       "omkafka_errors",
-			Type:       model.Counter,
-			Value:      0,
-			LabelValue: "other",
+			Name:   "omkafka_errors",
+			Type:   model.Counter,
+			Value:  0,
+			Labels: []model.Label{{Name: "type", Value: "other"}},
 		},
 		{
 			Name:  "omkafka_rtt_avg_usec_avg",
@@ -180,8 +180,8 @@ func TestOmkafkaToPoints(t *testing.T) {
 	for idx, tc := range testCases {
 		t.Run(fmt.Sprintf("point idx %d", idx), func(t *testing.T) {
 			p := points[idx]
-			want := th.PointExpectation{Name: tc.Name, Type: int(tc.Type), Value: tc.Value, Label: tc.LabelValue}
-			got := th.PointExpectation{Name: p.Name, Type: int(p.Type), Value: p.Value, Label: p.LabelValue}
+			want := th.PointExpectation{Name: tc.Name, Type: int(tc.Type), Value: tc.Value, Label: fmt.Sprint(tc.Labels)}
+			got := th.PointExpectation{Name: p.Name, Type: int(p.Type), Value: p.Value, Label: fmt.Sprint(p.Labels)}
 			th.AssertPointFields(t, idx, want, got)
 		})
 	}
@@ -14,8 +14,8 @@
 package rsyslog
 
 import (
-	"encoding/json"
 	"fmt"
+	"math"
 
 	"github.com/prometheus-community/rsyslog_exporter/internal/model"
 )
@@ -23,79 +23,144 @@ import (
 // Queue represents rsyslog queue statistics.
 type Queue struct {
 	Name          string `json:"name"`
-	Size          int64  `json:"size"`
-	Enqueued      int64  `json:"enqueued"`
-	Full          int64  `json:"full"`
-	DiscardedFull int64  `json:"discarded.full"`
-	DiscardedNf   int64  `json:"discarded.nf"`
-	MaxQsize      int64  `json:"maxqsize"`
+	Size          Number `json:"size"`
+	Enqueued      Number `json:"enqueued"`
+	Full          Number `json:"full"`
+	DiscardedFull Number `json:"discarded.full"`
+	DiscardedNf   Number `json:"discarded.nf"`
+	MaxQsize      Number `json:"maxqsize"`
 }
 
 func NewQueueFromJSON(b []byte) (*Queue, error) {
-	var pstat Queue
-	err := json.Unmarshal(b, &pstat)
-	if err != nil {
+	var f Fields
+	if err := f.Parse(b); err != nil {
 		return nil, fmt.Errorf("failed to decode queue stat `%v`: %w", string(b), err)
 	}
-	return &pstat, nil
+	return NewQueueFromFields(&f), nil
+}
+
+// NewQueueFromFields returns the queue stats of the parsed object f.
+func NewQueueFromFields(f *Fields) *Queue {
+	return &Queue{
+		Name:          f.String("name"),
+		Size:          f.Number("size"),
+		Enqueued:      f.Number("enqueued"),
+		Full:          f.Number("full"),
+		DiscardedFull: f.Number("discarded.full"),
+		DiscardedNf:   f.Number("discarded.nf"),
+		MaxQsize:      f.Number("maxqsize"),
+	}
 }
 
 func (q *Queue) ToPoints() []*model.Point {
 	points := make([]*model.Point, 6)
+	labels := q.labels()
 
 	points[0] = &model.Point{
 		Name:        "queue_size",
 		Type:        model.Gauge,
-		Value:       q.Size,
+		Value:       float64(q.Size),
 		Description: "messages currently in queue",
-		LabelName:   "queue",
-		LabelValue:  q.Name,
+		Labels:      labels,
 	}
 
 	points[1] = &model.Point{
 		Name:        "queue_enqueued",
 		Type:        model.Counter,
-		Value:       q.Enqueued,
+		Value:       float64(q.Enqueued),
 		Description: "total messages enqueued",
-		LabelName:   "queue",
-		LabelValue:  q.Name,
+		Labels:      labels,
 	}
 
 	points[2] = &model.Point{
 		Name:        "queue_full",
 		Type:        model.Counter,
-		Value:       q.Full,
+		Value:       float64(q.Full),
 		Description: "times queue was full",
-		LabelName:   "queue",
-		LabelValue:  q.Name,
+		Labels:      labels,
 	}
 
 	points[3] = &model.Point{
 		Name:        "queue_discarded_full",
 		Type:        model.Counter,
-		Value:       q.DiscardedFull,
+		Value:       float64(q.DiscardedFull),
 		Description: "messages discarded due to queue being full",
-		LabelName:   "queue",
-		LabelValue:  q.Name,
+		Labels:      labels,
 	}
 
 	points[4] = &model.Point{
 		Name:        "queue_discarded_not_full",
 		Type:        model.Counter,
-		Value:       q.DiscardedNf,
+		Value:       float64(q.DiscardedNf),
 		Description: "messages discarded when queue not full",
-		LabelName:   "queue",
-		LabelValue:  q.Name,
+		Labels:      labels,
 	}
 
 	points[5] = &model.Point{
 		Name:        "queue_max_size",
 		Type:        model.Gauge,
-		Value:       q.MaxQsize,
+		Value:       float64(q.MaxQsize),
 		Description: "maximum size queue has reached",
-		LabelName:   "queue",
-		LabelValue:  q.Name,
+		Labels:      labels,
+	}
+
+	if ParseName(q.Name).DA {
+		points = append(points, q.diskPoints(labels)...)
 	}
 
-	return points
+	return validPoints(points)
+}
+
+// diskPoints returns the series derived from the disk-assisted part of a
+// queue: everything in it is spooled to disk, and it is active while it
+// holds messages.
+func (q *Queue) diskPoints(labels []model.Label) []*model.Point {
+	active := math.NaN()
+	if q.Size.Valid() {
+		active = 0
+		if q.Size > 0 {
+			active = 1
+		}
+	}
+	return []*model.Point{
+		{
+			Name:        "queue_disk_spooled",
+			Type:        model.Gauge,
+			Value:       float64(q.Size),
+			Description: "messages currently spooled to disk",
+			Labels:      labels,
+		},
+		{
+			Name:        "queue_disk_assisted_active",
+			Type:        model.Gauge,
+			Value:       active,
+			Description: "whether the queue currently spills messages to disk",
+			Labels:      labels,
+		},
+	}
+}
+
+// Queue kinds, see Queue.labels.
+const (
+	queueKindPrimary      = "primary"
+	queueKindDiskAssisted = "disk_assisted"
+)
+
+// labels returns the labels of the series of the queue: its name, the
+// action or ruleset owning it and the index of that action, if any, and its
+// kind. The disk-assisted part of a
+// queue, "<queue>[DA]", is labelled by the name of its parent queue so that
+// both are linked.
+func (q *Queue) labels() []model.Label {
+	n := ParseName(q.Name)
+	kind := queueKindPrimary
+	if n.DA {
+		kind = queueKindDiskAssisted
+	}
+	return []model.Label{
+		{Name: "queue", Value: n.Base},
+		{Name: "owner", Value: n.Owner},
+		{Name: "action_index", Value: n.Action},
+		{Name: "queue_kind", Value: kind},
+	}
 }
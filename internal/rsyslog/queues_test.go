@@ -39,28 +39,28 @@ func TestNewQueueFromJSON(t *testing.T) {
 		t.Errorf(th.ExpectedActualStringFmt, want, got)
 	}
 
-	if want, got := int64(10), pstat.Size; want != got {
-		t.Errorf(th.ExpectedActualIntFmt, want, got)
+	if want, got := Number(10), pstat.Size; want != got {
+		t.Errorf(th.ExpectedActualFloatFmt, want, got)
 	}
 
-	if want, got := int64(20), pstat.Enqueued; want != got {
-		t.Errorf(th.ExpectedActualIntFmt, want, got)
+	if want, got := Number(20), pstat.Enqueued; want != got {
+		t.Errorf(th.ExpectedActualFloatFmt, want, got)
 	}
 
-	if want, got := int64(30), pstat.Full; want != got {
-		t.Errorf(th.ExpectedActualIntFmt, want, got)
+	if want, got := Number(30), pstat.Full; want != got {
+		t.Errorf(th.ExpectedActualFloatFmt, want, got)
 	}
 
-	if want, got := int64(40), pstat.DiscardedFull; want != got {
-		t.Errorf(th.ExpectedActualIntFmt, want, got)
+	if want, got := Number(40), pstat.DiscardedFull; want != got {
+		t.Errorf(th.ExpectedActualFloatFmt, want, got)
 	}
 
-	if want, got := int64(50), pstat.DiscardedNf; want != got {
-		t.Errorf(th.ExpectedActualIntFmt, want, got)
+	if want, got := Number(50), pstat.DiscardedNf; want != got {
+		t.Errorf(th.ExpectedActualFloatFmt, want, got)
 	}
 
-	if want, got := int64(60), pstat.MaxQsize; want != got {
-		t.Errorf(th.ExpectedActualIntFmt, want, got)
+	if want, got := Number(60), pstat.MaxQsize; want != got {
+		t.Errorf(th.ExpectedActualFloatFmt, want, got)
 	}
 }
 
@@ -74,7 +74,7 @@ func TestQueueToPoints(t *testing.T) {
 	type expectation struct {
 		idx        int
 		name       string
-		value      int64
+		value      float64
 		metricType model.PointType
 		labelValue string
 	}
@@ -93,7 +93,46 @@ func TestQueueToPoints(t *testing.T) {
 		}
 		pt := points[exp.idx]
 		want := th.PointExpectation{Name: exp.name, Type: int(exp.metricType), Value: exp.value, Label: exp.labelValue}
-		got := th.PointExpectation{Name: pt.Name, Type: int(pt.Type), Value: pt.Value, Label: pt.LabelValue}
+		got := th.PointExpectation{Name: pt.Name, Type: int(pt.Type), Value: pt.Value, Label: pt.Label("queue")}
 		th.AssertPointFields(t, exp.idx, want, got)
 	}
 }
+
+func TestDiskAssistedQueueToPoints(t *testing.T) {
+	pstat, err := NewQueueFromJSON([]byte(`{"name":"action 2 queue[DA]","size":15,"enqueued":20,"full":0,"discarded.full":0,"discarded.nf":0,"maxqsize":60}`))
+	if err != nil {
+		t.Fatalf("expected parsing queue stat not to fail, got: %v", err)
+	}
+	points := pstat.ToPoints()
+	if want, got := 8, len(points); want != got {
+		t.Fatalf(th.ExpectedActualIntFmt, want, got)
+	}
+	for _, p := range points {
+		th.AssertEqString(t, p.Name+" queue", "action 2 queue", p.Label("queue"))
+		th.AssertEqString(t, p.Name+" queue_kind", "disk_assisted", p.Label("queue_kind"))
+		th.AssertEqString(t, p.Name+" owner", "action 2", p.Label("owner"))
+		th.AssertEqString(t, p.Name+" action_index", "2", p.Label("action_index"))
+	}
+	th.AssertEqString(t, "spooled", "queue_disk_spooled", points[6].Name)
+	th.AssertEqFloat(t, "spooled", 15, points[6].Value)
+	th.AssertEqString(t, "active", "queue_disk_assisted_active", points[7].Name)
+	th.AssertEqFloat(t, "active", 1, points[7].Value)
+
+	pstat.Size = 0
+	points = pstat.ToPoints()
+	th.AssertEqFloat(t, "inactive", 0, points[7].Value)
+
+	// the parent queue has neither derived series
+	pstat.Name = "action 2 queue"
+	points = pstat.ToPoints()
+	if want, got := 6, len(points); want != got {
+		t.Fatalf(th.ExpectedActualIntFmt, want, got)
+	}
+	th.AssertEqString(t, "queue_kind", "primary", points[0].Label("queue_kind"))
+
+	// queues of named actions and rulesets are labelled by their owner only
+	pstat.Name = "omfwd queue"
+	points = pstat.ToPoints()
+	th.AssertEqString(t, "owner", "omfwd", points[0].Label("owner"))
+	th.AssertEqString(t, "action_index", "", points[0].Label("action_index"))
+}
@@ -14,7 +14,6 @@
 package rsyslog
 
 import (
-	"encoding/json"
 	"fmt"
 
 	"github.com/prometheus-community/rsyslog_exporter/internal/model"
@@ -23,24 +22,39 @@ import (
 // Resource represents rsyslog resource usage statistics.
 type Resource struct {
 	Name     string `json:"name"`
-	Utime    int64  `json:"utime"`
-	Stime    int64  `json:"stime"`
-	Maxrss   int64  `json:"maxrss"`
-	Minflt   int64  `json:"minflt"`
-	Majflt   int64  `json:"majflt"`
-	Inblock  int64  `json:"inblock"`
-	Outblock int64  `json:"outblock"`
-	Nvcsw    int64  `json:"nvcsw"`
-	Nivcsw   int64  `json:"nivcsw"`
+	Utime    Number `json:"utime"`
+	Stime    Number `json:"stime"`
+	Maxrss   Number `json:"maxrss"`
+	Minflt   Number `json:"minflt"`
+	Majflt   Number `json:"majflt"`
+	Inblock  Number `json:"inblock"`
+	Outblock Number `json:"outblock"`
+	Nvcsw    Number `json:"nvcsw"`
+	Nivcsw   Number `json:"nivcsw"`
 }
 
 func NewResourceFromJSON(b []byte) (*Resource, error) {
-	var resource Resource
-	err := json.Unmarshal(b, &resource)
-	if err != nil {
+	var f Fields
+	if err := f.Parse(b); err != nil {
 		return nil, fmt.Errorf("failed to decode resource stat `%v`: %w", string(b), err)
 	}
-	return &resource, nil
+	return NewResourceFromFields(&f), nil
+}
+
+// NewResourceFromFields returns the resource usage stats of the parsed object f.
+func NewResourceFromFields(f *Fields) *Resource {
+	return &Resource{
+		Name:     f.String("name"),
+		Utime:    f.Number("utime"),
+		Stime:    f.Number("stime"),
+		Maxrss:   f.Number("maxrss"),
+		Minflt:   f.Number("minflt"),
+		Majflt:   f.Number("majflt"),
+		Inblock:  f.Number("inblock"),
+		Outblock: f.Number("outblock"),
+		Nvcsw:    f.Number("nvcsw"),
+		Nivcsw:   f.Number("nivcsw"),
+	}
 }
 
 func (r *Resource) ToPoints() []*model.Point {
@@ -50,76 +64,67 @@ func (r *Resource) ToPoints() []*model.Point {
 		&model.Point{
 			Name:        "resource_utime",
 			Type:        model.Counter,
-			Value:       r.Utime,
+			Value:       float64(r.Utime),
 			Description: "user time used in microseconds",
-			LabelName:   "resource",
-			LabelValue:  r.Name,
+			Labels:      []model.Label{{Name: "resource", Value: r.Name}},
 		},
 		&model.Point{
 			Name:        "resource_stime",
 			Type:        model.Counter,
-			Value:       r.Stime,
+			Value:       float64(r.Stime),
 			Description: "system time used in microseconds",
-			LabelName:   "resource",
-			LabelValue:  r.Name,
+			Labels:      []model.Label{{Name: "resource", Value: r.Name}},
 		},
 		&model.Point{
 			Name:        "resource_maxrss",
 			Type:        model.Gauge,
-			Value:       r.Maxrss,
+			Value:       float64(r.Maxrss),
 			Description: "maximum resident set size",
-			LabelName:   "resource",
-			LabelValue:  r.Name,
+			Labels:      []model.Label{{Name: "resource", Value: r.Name}},
 		},
 		&model.Point{
 			Name:        "resource_minflt",
 			Type:        model.Counter,
-			Value:       r.Minflt,
+			Value:       float64(r.Minflt),
 			Description: "total minor faults",
-			LabelName:   "resource",
-			LabelValue:  r.Name,
+			Labels:      []model.Label{{Name: "resource", Value: r.Name}},
 		},
 		&model.Point{
 			Name:        "resource_majflt",
 			Type:        model.Counter,
-			Value:       r.Majflt,
+			Value:       float64(r.Majflt),
 			Description: "total major faults",
-			LabelName:   "resource",
-			LabelValue:  r.Name,
+			Labels:      []model.Label{{Name: "resource", Value: r.Name}},
 		},
 		&model.Point{
 			Name:        "resource_inblock",
 			Type:        model.Counter,
-			Value:       r.Inblock,
+			Value:       float64(r.Inblock),
 			Description: "filesystem input operations",
-			LabelName:   "resource",
-			LabelValue:  r.Name,
+			Labels:      []model.Label{{Name: "resource", Value: r.Name}},
 		},
 		&model.Point{
 			Name:        "resource_oublock",
 			Type:        model.Counter,
-			Value:       r.Outblock,
+			Value:       float64(r.Outblock),
 			Description: "filesystem output operations",
-			LabelName:   "resource",
-			LabelValue:  r.Name,
+			Labels:      []model.Label{{Name: "resource", Value: r.Name}},
 		},
 		&model.Point{
 			Name:        "resource_nvcsw",
 			Type:        model.Counter,
-			Value:       r.Nvcsw,
+			Value:       float64(r.Nvcsw),
 			Description: "voluntary context switches",
-			LabelName:   "resource",
-			LabelValue:  r.Name,
+			Labels:      []model.Label{{Name: "resource", Value: r.Name}},
 		},
 		&model.Point{
 			Name:        "resource_nivcsw",
 			Type:        model.Counter,
-			Value:       r.Nivcsw,
+			Value:       float64(r.Nivcsw),
 			Description: "involuntary context switches",
-			LabelName:   "resource",
-			LabelValue:  r.Name,
+			Labels:      []model.Label{{Name: "resource", Value: r.Name}},
 		},
 	)
 
-	return points
+	return validPoints(points)
 }
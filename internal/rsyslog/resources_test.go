@@ -39,40 +39,40 @@ func TestNewResourceFromJSON(t *testing.T) {
 		t.Errorf(th.ExpectedActualStringFmt, want, got)
 	}
 
-	if want, got := int64(10), pstat.Utime; want != got {
-		t.Errorf(th.ExpectedActualIntFmt, want, got)
+	if want, got := Number(10), pstat.Utime; want != got {
+		t.Errorf(th.ExpectedActualFloatFmt, want, got)
 	}
 
-	if want, got := int64(20), pstat.Stime; want != got {
-		t.Errorf(th.ExpectedActualIntFmt, want, got)
+	if want, got := Number(20), pstat.Stime; want != got {
+		t.Errorf(th.ExpectedActualFloatFmt, want, got)
 	}
 
-	if want, got := int64(30), pstat.Maxrss; want != got {
-		t.Errorf(th.ExpectedActualIntFmt, want, got)
+	if want, got := Number(30), pstat.Maxrss; want != got {
+		t.Errorf(th.ExpectedActualFloatFmt, want, got)
 	}
 
-	if want, got := int64(40), pstat.Minflt; want != got {
-		t.Errorf(th.ExpectedActualIntFmt, want, got)
+	if want, got := Number(40), pstat.Minflt; want != got {
+		t.Errorf(th.ExpectedActualFloatFmt, want, got)
 	}
 
-	if want, got := int64(50), pstat.Majflt; want != got {
-		t.Errorf(th.ExpectedActualIntFmt, want, got)
+	if want, got := Number(50), pstat.Majflt; want != got {
+		t.Errorf(th.ExpectedActualFloatFmt, want, got)
 	}
 
-	if want, got := int64(60), pstat.Inblock; want != got {
-		t.Errorf(th.ExpectedActualIntFmt, want, got)
+	if want, got := Number(60), pstat.Inblock; want != got {
+		t.Errorf(th.ExpectedActualFloatFmt, want, got)
 	}
 
-	if want, got := int64(70), pstat.Outblock; want != got {
-		t.Errorf(th.ExpectedActualIntFmt, want, got)
+	if want, got := Number(70), pstat.Outblock; want != got {
+		t.Errorf(th.ExpectedActualFloatFmt, want, got)
 	}
 
-	if want, got := int64(80), pstat.Nvcsw; want != got {
-		t.Errorf(th.ExpectedActualIntFmt, want, got)
+	if want, got := Number(80), pstat.Nvcsw; want != got {
+		t.Errorf(th.ExpectedActualFloatFmt, want, got)
 	}
 
-	if want, got := int64(90), pstat.Nivcsw; want != got {
-		t.Errorf(th.ExpectedActualIntFmt, want, got)
+	if want, got := Number(90), pstat.Nivcsw; want != got {
+		t.Errorf(th.ExpectedActualFloatFmt, want, got)
 	}
 }
 
@@ -86,7 +86,7 @@ func TestResourceToPoints(t *testing.T) {
 	type expectation struct {
 		idx        int
 		name       string
-		value      int64
+		value      float64
 		metricType model.PointType
 		labelValue string
 	}
@@ -108,7 +108,7 @@ func TestResourceToPoints(t *testing.T) {
 		}
 		pt := points[exp.idx]
 		want := th.PointExpectation{Name: exp.name, Type: int(exp.metricType), Value: exp.value, Label: exp.labelValue}
-		got := th.PointExpectation{Name: pt.Name, Type: int(pt.Type), Value: pt.Value, Label: pt.LabelValue}
+		got := th.PointExpectation{Name: pt.Name, Type: int(pt.Type), Value: pt.Value, Label: pt.Label("resource")}
 		th.AssertPointFields(t, exp.idx, want, got)
 	}
 }
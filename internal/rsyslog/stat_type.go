@@ -14,8 +14,7 @@
 package rsyslog
 
 import (
-	"bytes"
-	"encoding/json"
+	"fmt"
 	"strings"
 )
 
@@ -36,73 +35,167 @@ const (
 	TypeOmkafka
 )
 
+var typeNames = map[Type]string{
+	TypeUnknown:       "unknown",
+	TypeAction:        "action",
+	TypeInput:         "input",
+	TypeQueue:         "queue",
+	TypeResource:      "resource",
+	TypeDynStat:       "dynstat",
+	TypeDynafileCache: "dynafile_cache",
+	TypeInputIMDUP:    "input_imudp",
+	TypeForward:       "forward",
+	TypeKubernetes:    "kubernetes",
+	TypeOmkafka:       "omkafka",
+}
+
+// String returns the name of t as used in metric labels.
+func (t Type) String() string {
+	if name, ok := typeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("Type(%d)", int(t))
+}
+
+// Classification is the type of an impstats object together with the rule
+// it was derived from.
+type Classification struct {
+	Type Type
+	// Rule describes what matched, e.g. `origin "core.queue"`.
+	Rule string
+}
+
 // StatType detects the impstats message type from the raw JSON buffer.
 func StatType(buf []byte) Type {
-	// Only match "processed" as a JSON key to reduce risk of false identification.
-	if bytes.Contains(buf, []byte("\"processed\":")) {
-		return TypeAction
+	return Classify(buf).Type
+}
+
+// Classify detects the impstats message type from the raw JSON buffer. The
+// "origin" field rsyslog sets on every object decides, objects of unknown
+// origin are of unknown type. Objects of older rsyslog versions without
+// origin are classified by their keys and name, and lines that are not JSON
+// by substrings.
+func Classify(buf []byte) Classification {
+	var f Fields
+	if f.Parse(buf) != nil {
+		return ClassifyLine(buf)
 	}
-	// Use short variable declaration inside the condition to avoid calling
-	// detectByName twice (which would re-unmarshal JSON and waste CPU).
-	if t := detectByName(buf); t != TypeUnknown {
-		return t
+	return ClassifyFields(&f)
+}
+
+// ClassifyLine classifies a line that is not a JSON object by substrings.
+func ClassifyLine(buf []byte) Classification {
+	if t, word := detectBySubstring(string(buf)); t != TypeUnknown {
+		return Classification{Type: t, Rule: fmt.Sprintf("substring %q", word)}
 	}
-	return detectBySubstring(string(buf))
+	return Classification{Type: TypeUnknown, Rule: "no rule"}
 }
 
-// detectByName parses JSON and classifies based on the "name" field.
-// Returns TypeUnknown if parsing fails or no matching name is found.
-func detectByName(buf []byte) Type {
-	var statData map[string]any
-	if json.Unmarshal(buf, &statData) != nil {
-		// Unmarshal failed; classification falls back to substring heuristics.
-		// Returning TypeUnknown here keeps parsing cheap without logging noise.
-		return TypeUnknown
+// ClassifyFields classifies the parsed object f like Classify.
+func ClassifyFields(f *Fields) Classification {
+	if origin := f.String("origin"); origin != "" {
+		// heuristics would misclassify objects of new plugins, e.g. any
+		// output counting "submitted" as an input
+		if t := detectByOrigin(origin, f); t != TypeUnknown {
+			return Classification{Type: t, Rule: fmt.Sprintf("origin %q", origin)}
+		}
+		return Classification{Type: TypeUnknown, Rule: fmt.Sprintf("unknown origin %q", origin)}
 	}
-	// Directly assert the "name" field to a string to avoid an extra
-	// temporary variable for the intermediate map lookup.
-	s, ok := statData["name"].(string)
-	if !ok {
-		return TypeUnknown
+	if f.Has("processed") {
+		return Classification{Type: TypeAction, Rule: `key "processed"`}
 	}
-	if strings.HasPrefix(s, "mmkubernetes") {
-		return TypeKubernetes
+	if name := f.String("name"); name != "" {
+		if t := detectByName(name); t != TypeUnknown {
+			return Classification{Type: t, Rule: fmt.Sprintf("name %q", name)}
+		}
 	}
-	switch s {
-	case "omkafka":
-		return TypeOmkafka
-	case "omfwd":
-		return TypeForward
+	for _, k := range keyRules {
+		if f.Has(k.key) {
+			return Classification{Type: k.typ, Rule: fmt.Sprintf("key %q", k.key)}
+		}
 	}
-	return TypeUnknown
+	return Classification{Type: TypeUnknown, Rule: "no rule"}
 }
 
-// detectBySubstring falls back to substring heuristics when JSON parsing isn't available.
-func detectBySubstring(line string) Type {
-	// Other heuristics below are checked only if JSON parsing failed in detectByName.
-	if strings.Contains(line, "submitted") {
-		return TypeInput
+// originTypes maps the origins of impstats objects to their type.
+var originTypes = map[string]Type{
+	"core.action":     TypeAction,
+	"core.queue":      TypeQueue,
+	"impstats":        TypeResource,
+	"dynstats":        TypeDynStat,
+	"dynstats.bucket": TypeDynStat,
+	"omfile":          TypeDynafileCache,
+	"omfwd":           TypeForward,
+	"mmkubernetes":    TypeKubernetes,
+	"omkafka":         TypeOmkafka,
+}
+
+// detectByOrigin classifies an object by its origin. Input modules share
+// the input type, except for the per worker objects of imudp.
+func detectByOrigin(origin string, f *Fields) Type {
+	if t, ok := originTypes[origin]; ok {
+		return t
 	}
-	if strings.Contains(line, "called.recvmmsg") {
+	if origin == "imudp" && f.Has("called.recvmmsg") {
 		return TypeInputIMDUP
 	}
-	if strings.Contains(line, "enqueued") {
-		return TypeQueue
-	}
-	if strings.Contains(line, "utime") {
-		return TypeResource
+	if strings.HasPrefix(origin, "im") && f.Has("submitted") {
+		return TypeInput
 	}
-	if strings.Contains(line, "dynstats") {
-		return TypeDynStat
+	return TypeUnknown
+}
+
+// detectByName classifies an object without origin by its name.
+func detectByName(name string) Type {
+	if strings.HasPrefix(name, "mmkubernetes") {
+		return TypeKubernetes
 	}
-	if strings.Contains(line, "dynafile cache") {
+	if strings.HasPrefix(name, "dynafile cache") {
 		return TypeDynafileCache
 	}
-	if strings.Contains(line, "omfwd") {
+	switch name {
+	case "omkafka":
+		return TypeOmkafka
+	case "omfwd":
 		return TypeForward
 	}
-	if strings.Contains(line, "mmkubernetes") {
-		return TypeKubernetes
-	}
 	return TypeUnknown
 }
+
+// keyRules classify objects without origin or known name by the presence
+// of a characteristic key, in order.
+var keyRules = []struct {
+	key string
+	typ Type
+}{
+	{"called.recvmmsg", TypeInputIMDUP},
+	{"submitted", TypeInput},
+	{"enqueued", TypeQueue},
+	{"utime", TypeResource},
+}
+
+// substringRules classify lines that are not JSON, in order.
+var substringRules = []struct {
+	word string
+	typ  Type
+}{
+	{"submitted", TypeInput},
+	{"called.recvmmsg", TypeInputIMDUP},
+	{"enqueued", TypeQueue},
+	{"utime", TypeResource},
+	{"dynstats", TypeDynStat},
+	{"dynafile cache", TypeDynafileCache},
+	{"omfwd", TypeForward},
+	{"mmkubernetes", TypeKubernetes},
+}
+
+// detectBySubstring falls back to substring heuristics when JSON parsing
+// isn't available. It returns the word that matched.
+func detectBySubstring(line string) (Type, string) {
+	for _, r := range substringRules {
+		if strings.Contains(line, r.word) {
+			return r.typ, r.word
+		}
+	}
+	return TypeUnknown, ""
+}
@@ -74,3 +74,49 @@ func TestStatTypeProcessedShortcut(t *testing.T) {
 		t.Fatalf("expected TypeAction for processed substring, got %v", got)
 	}
 }
+
+func TestTypeString(t *testing.T) {
+	if want, got := "dynafile_cache", TypeDynafileCache.String(); want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+	if want, got := "Type(42)", Type(42).String(); want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestClassifyByOrigin(t *testing.T) {
+	cases := []struct {
+		line string
+		want Type
+		rule string
+	}{
+		// names containing heuristic words no longer mislead classification
+		{`{"name":"fwd submitted utime","origin":"core.action","processed":1}`, TypeAction, `origin "core.action"`},
+		{`{"name":"enqueued-in","origin":"imtcp","submitted":1}`, TypeInput, `origin "imtcp"`},
+		{`{"name":"utime queue","origin":"core.queue","enqueued":1}`, TypeQueue, `origin "core.queue"`},
+		{`{"name":"imudp(w0)","origin":"imudp","called.recvmmsg":1}`, TypeInputIMDUP, `origin "imudp"`},
+		{`{"name":"imudp(*:514)","origin":"imudp","submitted":1}`, TypeInput, `origin "imudp"`},
+		{`{"name":"resource-usage","origin":"impstats","utime":1}`, TypeResource, `origin "impstats"`},
+		{`{"name":"global","origin":"dynstats","values":{}}`, TypeDynStat, `origin "dynstats"`},
+		{`{"name":"msg_per_host","origin":"dynstats.bucket","values":{}}`, TypeDynStat, `origin "dynstats.bucket"`},
+		{`{"name":"dynafile cache cluster","origin":"omfile","requests":1}`, TypeDynafileCache, `origin "omfile"`},
+		{`{"name":"TCP-FQDN-6514","origin":"omfwd","bytes.sent":1}`, TypeForward, `origin "omfwd"`},
+		{`{"name":"mmkubernetes(https://k8s)","origin":"mmkubernetes"}`, TypeKubernetes, `origin "mmkubernetes"`},
+		{`{"name":"omkafka","origin":"omkafka","submitted":1}`, TypeOmkafka, `origin "omkafka"`},
+		// objects of unknown origin are left to the generic decoder
+		{`{"name":"omelasticsearch","origin":"omelasticsearch","submitted":5}`, TypeUnknown, `unknown origin "omelasticsearch"`},
+		{`{"name":"x","origin":"omfoo","enqueued":1}`, TypeUnknown, `unknown origin "omfoo"`},
+		{`{"name":"x","origin":"imfoo"}`, TypeUnknown, `unknown origin "imfoo"`},
+		// fallbacks for objects without origin
+		{`{"name":"omkafka","submitted":1}`, TypeOmkafka, `name "omkafka"`},
+		{`{"name":"x","enqueued":1}`, TypeQueue, `key "enqueued"`},
+		{"queue enqueued event", TypeQueue, `substring "enqueued"`},
+		{"nothing to see", TypeUnknown, "no rule"},
+	}
+	for _, c := range cases {
+		got := Classify([]byte(c.line))
+		if got.Type != c.want || got.Rule != c.rule {
+			t.Errorf("Classify(%s): want %v by %s, got %v by %s", c.line, c.want, c.rule, got.Type, got.Rule)
+		}
+	}
+}
@@ -40,6 +40,14 @@ func AssertEqInt(t tester, ctx string, want, got int64) {
 	}
 }
 
+// AssertEqFloat reports an error if want != got with context label.
+func AssertEqFloat(t tester, ctx string, want, got float64) {
+	t.Helper()
+	if want != got {
+		t.Errorf(ctx+": "+ExpectedActualFloatFmt, want, got)
+	}
+}
+
 // AssertPointFields compares a Point's fields against expected values and
 // reports errors. It accepts primitive types to avoid importing
 // the model package and creating import cycles.
@@ -51,7 +59,7 @@ func AssertEqInt(t tester, ctx string, want, got int64) {
 type PointExpectation struct {
 	Name  string
 	Type  int
-	Value int64
+	Value float64
 	Label string
 }
 
@@ -66,7 +74,7 @@ func AssertPointFields(t tester, idx int, want PointExpectation, got PointExpect
 		t.Errorf("%s: want type %d got %d", want.Name, want.Type, got.Type)
 	}
 	if got.Value != want.Value {
-		t.Errorf("%s: want value %d got %d", want.Name, want.Value, got.Value)
+		t.Errorf("%s: want value %v got %v", want.Name, want.Value, got.Value)
 	}
 	if got.Label != want.Label {
 		t.Errorf("%s: want label %s got %s", want.Name, want.Label, got.Label)